@@ -0,0 +1,62 @@
+package apierr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"schneider.vip/problem"
+)
+
+// CausesExtension is the problem extension listing the errors merged by Aggregate.
+const CausesExtension = "causes"
+
+// Aggregate merges the errors of a request failed for several independent reasons into
+// a single APIErr: the status is the highest one and each error appears as a problem in the
+// CausesExtension. Errors not convertible to a problem are listed as Internal Server Error,
+// without exposing their text. nil errors are ignored; Aggregate returns nil if all are nil.
+//
+// Example:
+//
+//	return apierr.Aggregate(validateCart(cart), checkStock(cart), checkCredit(user))
+func Aggregate(errs ...error) *APIErr {
+	return defaultRegistry.aggregate(errs)
+}
+
+// Aggregate is the Registry version of the package-level Aggregate: the errors are
+// converted to problems with the handlers and unwrappers of r.
+func (r *Registry) Aggregate(errs ...error) *APIErr {
+	return r.aggregate(errs)
+}
+
+// aggregate must be called directly by the Aggregate functions, see newAPIErr.
+func (r *Registry) aggregate(errs []error) *APIErr {
+	var merged []error
+	var causes []map[string]any
+	status := 0
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		merged = append(merged, err)
+		p, _ := r.extractProblem(err)
+		if p == nil {
+			p = InternalServerError.Problem(http.StatusText(http.StatusInternalServerError))
+		}
+		data := problemData(p)
+		delete(data, "reason")
+		causes = append(causes, data)
+		if s, _ := data["status"].(float64); int(s) > status {
+			status = int(s)
+		}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	e := newAPIErr(2, HttpStatus(status), errors.Join(merged...))
+	e.opts = append(e.opts,
+		problem.Detail(fmt.Sprintf("%d errors occurred", len(merged))),
+		problem.Custom(CausesExtension, causes),
+	)
+	return e
+}
@@ -0,0 +1,140 @@
+package apierr
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AnomalyConfig configures the anomaly detection, see DetectAnomalies.
+// The fields that are not positive take the values of DefaultAnomalyConfig.
+type AnomalyConfig struct {
+	// Window is the period over which the errors are counted.
+	Window time.Duration
+	// Factor is the ratio to the baseline above which a count is a spike.
+	Factor float64
+	// MinCount is the count below which a window is never anomalous, so that
+	// low-traffic codes do not raise alerts.
+	MinCount int
+	// Smoothing is the weight of the last window in the baseline (exponential moving average).
+	Smoothing float64
+	// Buffer is the size of the event subscription buffer.
+	Buffer int
+}
+
+// DefaultAnomalyConfig is the default AnomalyConfig.
+var DefaultAnomalyConfig = AnomalyConfig{Window: time.Minute, Factor: 3, MinCount: 10, Smoothing: 0.2, Buffer: 1024}
+
+// Anomaly is the error of the notifications sent by the anomaly detection.
+type Anomaly struct {
+	// Key is the problem type of the errors, "fallback" for the unknown errors
+	// replied with Internal Server Error, or the status when there is no type.
+	Key    string
+	Status int
+	// Count is the number of errors in the last window.
+	Count int
+	// Baseline is the usual number of errors per window, 0 for a new key.
+	Baseline float64
+}
+
+func (a *Anomaly) Error() string {
+	if a.Baseline == 0 {
+		return fmt.Sprintf("new error %s: %d occurrences", a.Key, a.Count)
+	}
+	return fmt.Sprintf("error %s spiking: %d occurrences, baseline %.1f", a.Key, a.Count, a.Baseline)
+}
+
+// DetectAnomalies runs the anomaly detection of the default registry, see Registry.DetectAnomalies.
+func DetectAnomalies(ctx context.Context, cfg AnomalyConfig) {
+	defaultRegistry.DetectAnomalies(ctx, cfg)
+}
+
+// DetectAnomalies starts a background analyzer of the events of r (see Subscribe) that
+// flags sudden shifts in the distribution of the errors: a new error kind, or one whose
+// count in a window exceeds Factor times its baseline. Anomalies are sent to the
+// notifiers of r as Notifications carrying an *Anomaly. The analyzer stops with ctx.
+//
+// Example:
+//
+//	apierr.AddNotifier(slackNotifier)
+//	apierr.DetectAnomalies(ctx, apierr.AnomalyConfig{Window: 5 * time.Minute})
+func (r *Registry) DetectAnomalies(ctx context.Context, cfg AnomalyConfig) {
+	cfg = cfg.withDefaults()
+	events, cancel := r.Subscribe(cfg.Buffer)
+	go func() {
+		defer cancel()
+		ticker := time.NewTicker(cfg.Window)
+		defer ticker.Stop()
+		baselines := map[string]float64{}
+		counts := map[string]int{}
+		statuses := map[string]int{}
+		// the first window only learns the baselines, since every key is new
+		learning := true
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e := <-events:
+				key := anomalyKey(e)
+				counts[key]++
+				statuses[key] = e.Status
+			case <-ticker.C:
+				for key, count := range counts {
+					baseline, known := baselines[key]
+					if !learning && count >= cfg.MinCount && (!known || float64(count) > cfg.Factor*baseline) {
+						r.notifyAnomaly(ctx, &Anomaly{Key: key, Status: statuses[key], Count: count, Baseline: baseline})
+					}
+				}
+				for key := range baselines {
+					if _, ok := counts[key]; !ok {
+						counts[key] = 0
+					}
+				}
+				for key, count := range counts {
+					if baseline, known := baselines[key]; known {
+						baselines[key] = baseline + cfg.Smoothing*(float64(count)-baseline)
+					} else {
+						baselines[key] = float64(count)
+					}
+				}
+				clear(counts)
+				learning = false
+			}
+		}
+	}()
+}
+
+func (cfg AnomalyConfig) withDefaults() AnomalyConfig {
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultAnomalyConfig.Window
+	}
+	if cfg.Factor <= 0 {
+		cfg.Factor = DefaultAnomalyConfig.Factor
+	}
+	if cfg.MinCount <= 0 {
+		cfg.MinCount = DefaultAnomalyConfig.MinCount
+	}
+	if cfg.Smoothing <= 0 {
+		cfg.Smoothing = DefaultAnomalyConfig.Smoothing
+	}
+	if cfg.Buffer <= 0 {
+		cfg.Buffer = DefaultAnomalyConfig.Buffer
+	}
+	return cfg
+}
+
+func anomalyKey(e Event) string {
+	switch {
+	case !e.Handled:
+		return "fallback"
+	case e.Code != "":
+		return e.Code
+	}
+	return fmt.Sprint(e.Status)
+}
+
+// notifyAnomaly notifies a; the title identifies the anomaly, so that Dedup
+// does not mix it up with the errors themselves.
+func (r *Registry) notifyAnomaly(ctx context.Context, a *Anomaly) {
+	r.deliver(ctx, Notification{Status: a.Status, Title: "anomaly " + a.Key, Err: a, Time: time.Now()})
+}
@@ -0,0 +1,217 @@
+package apierr
+
+import (
+	"errors"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+
+	"schneider.vip/problem"
+)
+
+// AppErrorHeader is the response header carrying the application error code (see APIErr.WithCode).
+// The extras of an APIErr are sent in the headers prefixed by AppErrorHeader+"-".
+const AppErrorHeader = "X-App-Error"
+
+// TrackOrigin enables the recording of the package that created each APIErr, see APIErr.Origin.
+// It costs a runtime.Caller per constructor call, hence it is disabled by default.
+// It applies to the whole process, since the errors are created before any registry handles them.
+var TrackOrigin = false
+
+// APIErr is an error carrying the HttpStatus to reply with.
+// It implements the go-kit StatusCoder and Headerer interfaces.
+//
+// Example:
+//
+//	return apierr.NotFound.Err(err).WithCode("user_not_found")
+type APIErr struct {
+	status  HttpStatus
+	err     error
+	code    string
+	extras  map[string]string
+	headers http.Header
+	opts    []problem.Option
+	origin  string
+	// upstreamTrace is the trace id of the failing response, see FromResponse.
+	upstreamTrace string
+	stack         []uintptr
+	// extErrs are the mismatches of WithExtension.
+	extErrs []error
+	// def is the Code the error was created with, see Code.Err.
+	def *Code
+}
+
+// New creates an APIErr replying with status. The err text is used as problem detail.
+func New(status HttpStatus, err error) *APIErr {
+	return newAPIErr(1, status, err)
+}
+
+// FromText creates an APIErr replying with status. The text is used as problem detail.
+func FromText(status HttpStatus, text string) *APIErr {
+	return newAPIErr(1, status, errors.New(text))
+}
+
+// Err creates an APIErr replying with h, see New.
+func (h HttpStatus) Err(err error) *APIErr {
+	return newAPIErr(1, h, err)
+}
+
+// newAPIErr creates the APIErr of the exported constructors. The origin and the stack
+// trace start from the caller of the constructor: skip is the number of frames between
+// newAPIErr and that caller, 1 when the constructor calls newAPIErr directly.
+func newAPIErr(skip int, status HttpStatus, err error) *APIErr {
+	e := &APIErr{status: status, err: err}
+	// +2 skips newAPIErr and the helper capturing the stack
+	if TrackOrigin {
+		e.origin = callerPackage(skip + 2)
+	}
+	if stackTraces {
+		e.stack = callers(skip + 2)
+	}
+	return e
+}
+
+// Error returns the text of the wrapped error, or the status text if there is none.
+func (e *APIErr) Error() string {
+	if e.err == nil {
+		return http.StatusText(int(e.status))
+	}
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *APIErr) Unwrap() error {
+	return e.err
+}
+
+// StatusCode returns the http status code of the error.
+func (e *APIErr) StatusCode() int {
+	return int(e.status)
+}
+
+// WithCode sets the application error code, sent to the clients in the AppErrorHeader
+// as Prefix + "." + code.
+func (e *APIErr) WithCode(code string) *APIErr {
+	e.code = code
+	e.def = nil
+	return e
+}
+
+// Code returns the application error code, empty if not set.
+func (e *APIErr) Code() string {
+	return e.code
+}
+
+// WithExtra sets an extra value, sent to the clients in the AppErrorHeader+"-"+key header.
+func (e *APIErr) WithExtra(key, value string) *APIErr {
+	if e.extras == nil {
+		e.extras = map[string]string{}
+	}
+	e.extras[key] = value
+	return e
+}
+
+// WithExtension sets the extension member key of the problem body to value, for the
+// structured data (IDs, limits, timestamps...) not fitting the string extras.
+// When the default registry has StrictExtensions, a value not matching the type of the
+// extension in its catalog (see RegisterType) is not set: the mismatch is recorded
+// instead, see ExtensionErr.
+//
+// Example:
+//
+//	return apierr.Conflict.Err(err).WithExtension("conflicting_ids", ids)
+func (e *APIErr) WithExtension(key string, value any) *APIErr {
+	if err := defaultRegistry.checkExtension("", key, value); err != nil {
+		e.extErrs = append(e.extErrs, err)
+		return e
+	}
+	e.opts = append(e.opts, problem.Custom(key, value))
+	return e
+}
+
+// ExtensionErr returns the *ExtensionMismatchError of the values rejected by WithExtension,
+// joined with errors.Join; nil if there is none. The registries log them along with
+// the error, see Registry.Logger.
+func (e *APIErr) ExtensionErr() error {
+	return errors.Join(e.extErrs...)
+}
+
+// WithHeader sets a response header written along with the error.
+func (e *APIErr) WithHeader(key, value string) *APIErr {
+	if e.headers == nil {
+		e.headers = http.Header{}
+	}
+	e.headers.Set(key, value)
+	return e
+}
+
+// Extras returns the extra values of the error.
+func (e *APIErr) Extras() map[string]string {
+	return e.extras
+}
+
+// Origin returns the import path of the package that created the error.
+// It is empty unless TrackOrigin is enabled.
+func (e *APIErr) Origin() string {
+	return e.origin
+}
+
+// Headers returns the response headers of the error.
+func (e *APIErr) Headers() http.Header {
+	h := e.headers.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	if e.code != "" {
+		h.Set(AppErrorHeader, Prefix+"."+e.code)
+	}
+	for k, v := range e.extras {
+		h.Set(AppErrorHeader+"-"+k, v)
+	}
+	return h
+}
+
+// Problem converts the error to a problem.Problem.
+func (e *APIErr) Problem() *problem.Problem {
+	p := problem.Of(int(e.status))
+	if e.err != nil {
+		p.Append(problem.Detail(e.err.Error()), problem.WrapSilent(e.err))
+	}
+	if c, ok := defaultRegistry.codeOf(e); ok {
+		p.Append(c.options()...)
+	}
+	return p.Append(e.opts...)
+}
+
+// OriginOf returns the origin of the first APIErr found in the chain of err, see APIErr.Origin.
+func OriginOf(err error) string {
+	var e *APIErr
+	if errors.As(err, &e) {
+		return e.origin
+	}
+	return ""
+}
+
+var callerPackages sync.Map
+
+// callerPackage returns the import path of the package of the function skip frames up the stack.
+func callerPackage(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip)
+	if !ok {
+		return ""
+	}
+	if pkg, ok := callerPackages.Load(pc); ok {
+		return pkg.(string)
+	}
+	pkg := ""
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		name := fn.Name()
+		slash := strings.LastIndex(name, "/") + 1
+		if dot := strings.Index(name[slash:], "."); dot >= 0 {
+			pkg = name[:slash+dot]
+		}
+	}
+	callerPackages.Store(pc, pkg)
+	return pkg
+}
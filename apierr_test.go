@@ -0,0 +1,80 @@
+package apierr_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/debyten/apierr"
+)
+
+const testPackage = "github.com/debyten/apierr_test"
+
+func TestOriginAndStack(t *testing.T) {
+	apierr.TrackOrigin = true
+	apierr.EnableStackTraces(true)
+	t.Cleanup(func() {
+		apierr.TrackOrigin = false
+		apierr.EnableStackTraces(false)
+	})
+	r := apierr.NewRegistry()
+	code := r.Register("order_locked", apierr.Locked, "order locked")
+	ranged := httptest.NewRequest(http.MethodGet, "/", nil)
+	ranged.Header.Set("Range", "bytes=100-")
+	conditional := httptest.NewRequest(http.MethodPut, "/", nil)
+	conditional.Header.Set("If-Match", `"v0"`)
+	tests := []struct {
+		name string
+		err  func() error
+	}{
+		{"New", func() error { return apierr.New(apierr.Conflict, errors.New("x")) }},
+		{"FromText", func() error { return apierr.FromText(apierr.Conflict, "x") }},
+		{"HttpStatus.Err", func() error { return apierr.Conflict.Err(errors.New("x")) }},
+		{"Code.Err", func() error { return code.Err(errors.New("x")) }},
+		{"Code.Text", func() error { return code.Text("x") }},
+		{"Unauthenticated", func() error { return apierr.Unauthenticated("Bearer", "api", nil) }},
+		{"ProblemWithRetry", func() error { return apierr.ServiceUnavailable.ProblemWithRetry("x", time.Second) }},
+		{"RateLimited", func() error { return apierr.RateLimited(10, 0, time.Now()) }},
+		{"RangeNotSatisfiable", func() error { return apierr.RangeNotSatisfiable(10) }},
+		{"CheckRange", func() error { return apierr.CheckRange(ranged, `"v1"`, time.Time{}, 10) }},
+		{"CheckPreconditions", func() error { return apierr.CheckPreconditions(conditional, `"v1"`, time.Time{}) }},
+		{"Aggregate", func() error { return apierr.Aggregate(errors.New("x")) }},
+		{"Registry.Aggregate", func() error { return r.Aggregate(errors.New("x")) }},
+		{"Backpressure", func() error { return apierr.Backpressure(apierr.ErrPoolExhausted) }},
+		{"Registry.Backpressure", func() error { return r.Backpressure(apierr.ErrPoolExhausted) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e *apierr.APIErr
+			if !errors.As(tt.err(), &e) {
+				t.Fatal("not an APIErr")
+			}
+			if got := e.Origin(); got != testPackage {
+				t.Errorf("origin = %q, want %q", got, testPackage)
+			}
+			pcs := e.StackTrace()
+			if len(pcs) == 0 {
+				t.Fatal("no stack trace")
+			}
+			if f, _ := runtime.CallersFrames(pcs).Next(); !strings.HasPrefix(f.Function, testPackage+".") {
+				t.Errorf("stack starts at %s, want the test", f.Function)
+			}
+		})
+	}
+}
+
+func TestLimitExceededOrigin(t *testing.T) {
+	apierr.TrackOrigin = true
+	t.Cleanup(func() { apierr.TrackOrigin = false })
+	var origin string
+	r := apierr.NewRegistry()
+	r.AddObserver(apierr.ObserverFunc(func(o apierr.Outcome, _ error, _ *http.Request) { origin = o.Origin }))
+	r.LimitExceeded(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if origin != testPackage {
+		t.Errorf("origin = %q, want %q", origin, testPackage)
+	}
+}
@@ -0,0 +1,132 @@
+package apierrtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/debyten/apierr"
+	"schneider.vip/problem"
+)
+
+// Adapter returns a handler failing with err through the framework integration under
+// test, which must reply the errors with r. For example, for a go-kit integration:
+//
+//	func(r *apierr.Registry, err error) http.Handler {
+//		return httptransport.NewServer(
+//			func(context.Context, any) (any, error) { return nil, err },
+//			decodeNothing, encodeNothing,
+//			httptransport.ServerErrorEncoder(kitadapter.RegistryErrorEncoder(r)),
+//		)
+//	}
+type Adapter func(r *apierr.Registry, err error) http.Handler
+
+// ConformanceExtension is the problem extension set by the decorator of the conformance suite.
+const ConformanceExtension = "conformance"
+
+// conformanceAccepts are the Accept headers of the requests of the conformance suite.
+var conformanceAccepts = []string{"", "application/json", "application/problem+xml", "text/plain", "text/html"}
+
+// Conformance runs the conformance suite against adapter: for a corpus of errors and
+// Accept headers, the responses of the integration must have the status, headers and
+// body of the responses of the core implementation (Registry.HandleRequestISE),
+// including the effects of a decorator.
+//
+// Example:
+//
+//	func TestConformance(t *testing.T) {
+//		apierrtest.Conformance(t, newGinAdapter)
+//	}
+func Conformance(t *testing.T, adapter Adapter) {
+	t.Helper()
+	r := conformanceRegistry()
+	for _, c := range conformanceCorpus() {
+		for _, accept := range conformanceAccepts {
+			t.Run(c.name+"/"+accept, func(t *testing.T) {
+				want, got := httptest.NewRecorder(), httptest.NewRecorder()
+				r.HandleRequestISE(c.err, want, conformanceRequest(accept))
+				adapter(r, c.err).ServeHTTP(got, conformanceRequest(accept))
+				normalizeXML(want)
+				normalizeXML(got)
+				for _, d := range diffResponses(c.err, want, got) {
+					t.Error(d)
+				}
+			})
+		}
+	}
+}
+
+// conformanceRegistry returns the registry of the conformance suite, with a decorator
+// setting the ConformanceExtension.
+func conformanceRegistry() *apierr.Registry {
+	r := apierr.NewRegistry()
+	r.AddDecorator(func(_ context.Context, _ error, p *problem.Problem) {
+		p.Append(problem.Custom(ConformanceExtension, true))
+	})
+	return r
+}
+
+type conformanceCase struct {
+	name string
+	err  error
+}
+
+func conformanceCorpus() []conformanceCase {
+	return []conformanceCase{
+		{"apierr", apierr.NotFound.Err(errors.New("user not found")).WithCode("user_not_found").WithExtra("id", "42")},
+		{"header", apierr.ServiceUnavailable.Err(errors.New("maintenance")).WithHeader("Retry-After", "120")},
+		{"problem", apierr.Conflict.Problem("version conflict")},
+		{"validation", apierr.Validation().Field("email", "must be valid")},
+		{"unknown", errors.New("boom")},
+	}
+}
+
+func conformanceRequest(accept string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/conformance", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	return req
+}
+
+// normalizeXML rewrites an XML problem body as its sorted members, since the order
+// of the members of a problem is not defined.
+func normalizeXML(rec *httptest.ResponseRecorder) {
+	if !strings.Contains(rec.Header().Get("Content-Type"), "xml") {
+		return
+	}
+	dec := xml.NewDecoder(bytes.NewReader(rec.Body.Bytes()))
+	var members []string
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 {
+				members = append(members, t.Name.Local+"=")
+			}
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth >= 2 {
+				members[len(members)-1] += string(t)
+			}
+		}
+	}
+	sort.Strings(members)
+	rec.Body = bytes.NewBufferString(strings.Join(members, "\n"))
+}
@@ -0,0 +1,76 @@
+package apierrtest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/debyten/apierr"
+)
+
+// wrapAdapter is the core integration: Registry.Wrap.
+func wrapAdapter(r *apierr.Registry, err error) http.Handler {
+	return r.Wrap(func(http.ResponseWriter, *http.Request) error { return err })
+}
+
+func TestConformanceWrap(t *testing.T) {
+	Conformance(t, wrapAdapter)
+}
+
+func TestConformanceDetectsDifferences(t *testing.T) {
+	adapters := map[string]Adapter{
+		// ignores the registry: no decorator
+		"default registry": func(_ *apierr.Registry, err error) http.Handler {
+			return apierr.DefaultRegistry().Wrap(func(http.ResponseWriter, *http.Request) error { return err })
+		},
+		// ignores the Accept header
+		"no negotiation": func(r *apierr.Registry, err error) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				r.HandleISE(err, w)
+			})
+		},
+	}
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			r := conformanceRegistry()
+			failed := 0
+			for _, c := range conformanceCorpus() {
+				for _, accept := range conformanceAccepts {
+					want, got := httptest.NewRecorder(), httptest.NewRecorder()
+					wrapAdapter(r, c.err).ServeHTTP(want, conformanceRequest(accept))
+					adapter(r, c.err).ServeHTTP(got, conformanceRequest(accept))
+					normalizeXML(want)
+					normalizeXML(got)
+					if len(diffResponses(c.err, want, got)) > 0 {
+						failed++
+					}
+				}
+			}
+			if failed == 0 {
+				t.Error("no difference detected")
+			}
+		})
+	}
+}
+
+func TestNormalizeXML(t *testing.T) {
+	bodies := []string{
+		`<problem xmlns="urn:ietf:rfc:7807"><title>Not Found</title><status>404</status></problem>`,
+		`<problem xmlns="urn:ietf:rfc:7807"><status>404</status><title>Not Found</title></problem>`,
+	}
+	var normalized []string
+	for _, body := range bodies {
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/problem+xml")
+		_, _ = io.WriteString(rec, body)
+		normalizeXML(rec)
+		normalized = append(normalized, rec.Body.String())
+	}
+	if normalized[0] != normalized[1] {
+		t.Errorf("normalized bodies differ: %q != %q", normalized[0], normalized[1])
+	}
+	if want := "status=404\ntitle=Not Found"; normalized[0] != want {
+		t.Errorf("normalized body = %q, want %q", normalized[0], want)
+	}
+}
@@ -0,0 +1,85 @@
+// Package apierrtest provides utilities to test the error handling configuration of a service.
+package apierrtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+)
+
+// HandleFunc writes the response for err, e.g. apierr.HandleISE.
+type HandleFunc func(err error, w http.ResponseWriter)
+
+// Difference is a difference between the responses produced for Err.
+type Difference struct {
+	Err error
+	// Field is the part of the response that differs: "status",
+	// "header <name>", "body <member>" or "body" when the body is not a JSON object.
+	Field string
+	Old   string
+	New   string
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%v: %s: %q != %q", d.Err, d.Field, d.Old, d.New)
+}
+
+// Diff runs the old and the new configuration over a corpus of errors and reports
+// the response differences (status, headers and body members), so that a change to the
+// error mappings can be reviewed before being shipped.
+//
+// Example:
+//
+//	diffs := apierrtest.Diff(legacy.HandleISE, apierr.HandleISE, corpus)
+//	for _, d := range diffs {
+//		t.Error(d)
+//	}
+func Diff(old, new HandleFunc, corpus []error) []Difference {
+	var diffs []Difference
+	for _, err := range corpus {
+		o, n := httptest.NewRecorder(), httptest.NewRecorder()
+		old(err, o)
+		new(err, n)
+		diffs = append(diffs, diffResponses(err, o, n)...)
+	}
+	return diffs
+}
+
+func diffResponses(err error, o, n *httptest.ResponseRecorder) []Difference {
+	var diffs []Difference
+	add := func(field, old, new string) {
+		if old != new {
+			diffs = append(diffs, Difference{Err: err, Field: field, Old: old, New: new})
+		}
+	}
+	add("status", strconv.Itoa(o.Code), strconv.Itoa(n.Code))
+	for _, k := range unionKeys(o.Header(), n.Header()) {
+		add("header "+k, o.Header().Get(k), n.Header().Get(k))
+	}
+	var ob, nb map[string]json.RawMessage
+	if json.Unmarshal(o.Body.Bytes(), &ob) != nil || json.Unmarshal(n.Body.Bytes(), &nb) != nil {
+		add("body", o.Body.String(), n.Body.String())
+		return diffs
+	}
+	for _, k := range unionKeys(ob, nb) {
+		add("body "+k, string(ob[k]), string(nb[k]))
+	}
+	return diffs
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,84 @@
+package apierrtest_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/debyten/apierr"
+	"github.com/debyten/apierr/apierrtest"
+)
+
+var errMissing = errors.New("missing")
+
+func TestDiffIdentical(t *testing.T) {
+	r := apierr.NewRegistry()
+	corpus := []error{apierr.NotFound.Err(errMissing), errMissing, apierr.Conflict.Problem("conflict")}
+	if diffs := apierrtest.Diff(r.HandleISE, r.HandleISE, corpus); len(diffs) != 0 {
+		t.Errorf("diffs = %v, want none", diffs)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := apierr.NewRegistry()
+	new := apierr.NewRegistry()
+	new.Map(errMissing, apierr.NotFound, "missing")
+	retry := apierr.ServiceUnavailable.Err(errors.New("maintenance"))
+	newRetry := func(err error, w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "60")
+		new.HandleISE(err, w)
+	}
+	tests := []struct {
+		name     string
+		old, new apierrtest.HandleFunc
+		err      error
+		want     []apierrtest.Difference
+	}{
+		{"mapped sentinel", old.HandleISE, new.HandleISE, errMissing, []apierrtest.Difference{
+			{Field: "header Content-Type", Old: "text/plain; charset=utf-8", New: "application/problem+json"},
+			{Field: "header X-Content-Type-Options", Old: "nosniff", New: ""},
+			{Field: "status", Old: "500", New: "404"},
+			{Field: "body", Old: "Internal Server Error\n", New: `{"status":404,"title":"missing"}`},
+		}},
+		{"header", old.HandleISE, newRetry, retry, []apierrtest.Difference{
+			{Field: "header Retry-After", Old: "", New: "60"},
+		}},
+		{"unchanged", old.HandleISE, new.HandleISE, apierr.Conflict.Err(errors.New("v2")), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := apierrtest.Diff(tt.old, tt.new, []error{tt.err})
+			if len(got) != len(tt.want) {
+				t.Fatalf("diffs = %v, want %v", got, tt.want)
+			}
+			for _, w := range tt.want {
+				w.Err = tt.err
+				if !contains(got, w) {
+					t.Errorf("diffs = %v, want %v among them", got, w)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffBodyMembers(t *testing.T) {
+	r := apierr.NewRegistry()
+	detailed := func(err error, w http.ResponseWriter) {
+		r.HandleISE(apierr.BadRequest.Err(errors.New("new detail")), w)
+	}
+	err := apierr.BadRequest.Err(errors.New("old detail"))
+	got := apierrtest.Diff(r.HandleISE, detailed, []error{err})
+	want := apierrtest.Difference{Err: err, Field: "body detail", Old: `"old detail"`, New: `"new detail"`}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("diffs = %v, want [%v]", got, want)
+	}
+}
+
+func contains(diffs []apierrtest.Difference, d apierrtest.Difference) bool {
+	for _, got := range diffs {
+		if got == d {
+			return true
+		}
+	}
+	return false
+}
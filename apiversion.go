@@ -0,0 +1,53 @@
+package apierr
+
+import (
+	"context"
+	"net/http"
+
+	"schneider.vip/problem"
+)
+
+// APIVersionExtension is the problem extension carrying the API version of the matched route.
+const APIVersionExtension = "api_version"
+
+type apiVersionKey struct{}
+
+// WithAPIVersion returns a copy of ctx carrying the API version of the matched route.
+// The problems handled for a request with that context have the APIVersionExtension and
+// a versioned type URI (see Registry.VersionedType).
+func WithAPIVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, apiVersionKey{}, version)
+}
+
+// APIVersion returns the API version carried by ctx.
+func APIVersion(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(apiVersionKey{}).(string)
+	return v, ok
+}
+
+// APIVersionMiddleware returns a middleware recording version in the request context.
+//
+// Example:
+//
+//	mux.Handle("/v2/", apierr.APIVersionMiddleware("v2")(v2Routes))
+func APIVersionMiddleware(version string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithAPIVersion(r.Context(), version)))
+		})
+	}
+}
+
+// applyAPIVersion sets the APIVersionExtension and the versioned type of p, on a copy.
+func (r *Registry) applyAPIVersion(ctx context.Context, p *problem.Problem) *problem.Problem {
+	version, ok := APIVersion(ctx)
+	if !ok {
+		return p
+	}
+	typeURI, _ := problemData(p)["type"].(string)
+	p = cloneProblem(p).Append(problem.Custom(APIVersionExtension, version))
+	if typeURI != "" && r.VersionedType != nil {
+		p.Append(problem.Type(r.VersionedType(typeURI, version)))
+	}
+	return p
+}
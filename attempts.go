@@ -0,0 +1,32 @@
+package apierr
+
+import (
+	"schneider.vip/problem"
+)
+
+// AttemptsExtension is the problem extension carrying the number of attempts
+// left before lockout or rate limiting (e.g. login attempts).
+const AttemptsExtension = "attempts_remaining"
+
+// AttemptCounter reports the attempts left for a key (user id, client ip...).
+// ok is false when the key is unknown to the counter.
+type AttemptCounter interface {
+	AttemptsRemaining(key string) (remaining int, ok bool)
+}
+
+// WithAttemptsRemaining sets the AttemptsExtension on p with the value reported by c for key.
+// p is returned unchanged when c does not know key.
+//
+// Example:
+//
+//	return apierr.WithAttemptsRemaining(apierr.Unauthorized.Problem("invalid credentials"), lockout, username)
+func WithAttemptsRemaining(p *problem.Problem, c AttemptCounter, key string) *problem.Problem {
+	remaining, ok := c.AttemptsRemaining(key)
+	if !ok {
+		return p
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	return p.Append(problem.Custom(AttemptsExtension, remaining))
+}
@@ -0,0 +1,75 @@
+package apierr
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"schneider.vip/problem"
+)
+
+// ErrUnauthenticated is the error wrapped by the errors of Unauthenticated.
+var ErrUnauthenticated = errors.New("authentication required")
+
+// Bearer token error codes (RFC 6750), the "error" parameter of the challenge.
+const (
+	BearerInvalidRequest    = "invalid_request"
+	BearerInvalidToken      = "invalid_token"
+	BearerInsufficientScope = "insufficient_scope"
+)
+
+// bearerStatuses are the statuses of the Bearer error codes not replied with 401.
+var bearerStatuses = map[string]HttpStatus{
+	BearerInvalidRequest:    BadRequest,
+	BearerInsufficientScope: Forbidden,
+}
+
+// Unauthenticated returns a 401 error challenging the client with the WWW-Authenticate
+// header: scheme, realm and the auth-params of params, quoted and in a stable order.
+// The "error_description" param, if any, is the problem detail.
+//
+// As mandated by RFC 6750, a Bearer challenge with the invalid_request error is replied
+// with 400 and one with insufficient_scope with 403.
+//
+// Example:
+//
+//	return apierr.Unauthenticated("Bearer", "api", map[string]string{
+//		"error":             apierr.BearerInvalidToken,
+//		"error_description": "the access token expired",
+//	})
+func Unauthenticated(scheme, realm string, params map[string]string) *APIErr {
+	status := Unauthorized
+	if strings.EqualFold(scheme, "Bearer") {
+		if s, ok := bearerStatuses[params["error"]]; ok {
+			status = s
+		}
+	}
+	e := newAPIErr(1, status, ErrUnauthenticated).WithHeader("WWW-Authenticate", challenge(scheme, realm, params))
+	if desc := params["error_description"]; desc != "" {
+		e.opts = append(e.opts, problem.Detail(desc))
+	}
+	return e
+}
+
+// challenge formats a WWW-Authenticate challenge (RFC 9110): the realm first, then
+// the params sorted by name.
+func challenge(scheme, realm string, params map[string]string) string {
+	var parts []string
+	if realm != "" {
+		parts = append(parts, `realm="`+quotedStringEscaper.Replace(realm)+`"`)
+	}
+	names := make([]string, 0, len(params))
+	for name := range params {
+		if name != "realm" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, name+`="`+quotedStringEscaper.Replace(params[name])+`"`)
+	}
+	if len(parts) == 0 {
+		return scheme
+	}
+	return scheme + " " + strings.Join(parts, ", ")
+}
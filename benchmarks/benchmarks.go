@@ -0,0 +1,176 @@
+// Package benchmarks contains representative scenarios of the error path, which runs
+// on every failed request. They are run by cmd/apierrbench, which gates regressions
+// against a recorded baseline.
+package benchmarks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/debyten/apierr"
+	"schneider.vip/problem"
+)
+
+// Scenario is a benchmark of the error path.
+type Scenario struct {
+	Name  string
+	Bench func(b *testing.B)
+}
+
+// Scenarios are the benchmarked scenarios.
+var Scenarios = []Scenario{
+	{Name: "StaticProblem", Bench: staticProblem},
+	{Name: "APIErr", Bench: apiErr},
+	{Name: "HandlerChainMiss", Bench: handlerChainMiss},
+	{Name: "ValidationPayload", Bench: validationPayload},
+	{Name: "Localized", Bench: localized},
+}
+
+func staticProblem(b *testing.B) {
+	p := apierr.NotFound.Problem("entity not found")
+	run(b, p)
+}
+
+func apiErr(b *testing.B) {
+	err := fmt.Errorf("find user: %w", apierr.NotFound.Err(os.ErrNotExist).WithCode("user_not_found"))
+	run(b, err)
+}
+
+func handlerChainMiss(b *testing.B) {
+	err := fmt.Errorf("query: %w", errors.New("connection reset"))
+	run(b, err)
+}
+
+func validationPayload(b *testing.B) {
+	violations := make([]map[string]string, 20)
+	for i := range violations {
+		violations[i] = map[string]string{"field": fmt.Sprintf("field%d", i), "message": "must not be empty"}
+	}
+	p := apierr.UnprocessableEntity.Problem("validation failed").Append(problem.Custom("errors", violations))
+	run(b, p)
+}
+
+func localized(b *testing.B) {
+	r := apierr.NewRegistry()
+	err := r.RegisterTranslations("it", map[string]apierr.Message{
+		"quota_exceeded": {Title: "Quota superata", Detail: "Limite di {{.limit}} richieste raggiunto"},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	ae := apierr.TooManyRequests.Err(errors.New("quota exceeded")).WithCode("quota_exceeded")
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "it-IT,it;q=0.9,en;q=0.8")
+	w := &discardWriter{header: http.Header{}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		clear(w.header)
+		r.HandleRequestISE(ae, w, req)
+	}
+}
+
+func run(b *testing.B, err error) {
+	w := &discardWriter{header: http.Header{}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		clear(w.header)
+		apierr.HandleISE(err, w)
+	}
+}
+
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+// Result is the outcome of a Scenario.
+type Result struct {
+	Name        string  `json:"name"`
+	N           int     `json:"n"`
+	NsPerOp     float64 `json:"ns_per_op"`
+	AllocsPerOp int64   `json:"allocs_per_op"`
+	BytesPerOp  int64   `json:"bytes_per_op"`
+}
+
+// Run runs all the Scenarios.
+func Run() []Result {
+	results := make([]Result, 0, len(Scenarios))
+	for _, s := range Scenarios {
+		r := testing.Benchmark(s.Bench)
+		results = append(results, Result{
+			Name:        s.Name,
+			N:           r.N,
+			NsPerOp:     float64(r.T.Nanoseconds()) / float64(r.N),
+			AllocsPerOp: r.AllocsPerOp(),
+			BytesPerOp:  r.AllocedBytesPerOp(),
+		})
+	}
+	return results
+}
+
+// WriteBenchfmt writes results in the Go benchmark format, readable by benchstat.
+func WriteBenchfmt(w io.Writer, results []Result) error {
+	for _, r := range results {
+		_, err := fmt.Fprintf(w, "Benchmark%s\t%d\t%.2f ns/op\t%d B/op\t%d allocs/op\n", r.Name, r.N, r.NsPerOp, r.BytesPerOp, r.AllocsPerOp)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Regression is a Scenario slower or allocating more than its baseline.
+type Regression struct {
+	Baseline Result
+	Current  Result
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s: %.2f ns/op -> %.2f ns/op, %d allocs/op -> %d allocs/op",
+		r.Current.Name, r.Baseline.NsPerOp, r.Current.NsPerOp, r.Baseline.AllocsPerOp, r.Current.AllocsPerOp)
+}
+
+// Compare returns the scenarios of current whose time per operation grew more than
+// threshold (e.g. 0.1 for 10%) or whose allocations per operation grew, with respect to baseline.
+// Scenarios missing from baseline are ignored.
+func Compare(baseline, current []Result, threshold float64) []Regression {
+	base := make(map[string]Result, len(baseline))
+	for _, r := range baseline {
+		base[r.Name] = r
+	}
+	var regressions []Regression
+	for _, r := range current {
+		b, ok := base[r.Name]
+		if !ok {
+			continue
+		}
+		if r.NsPerOp > b.NsPerOp*(1+threshold) || r.AllocsPerOp > b.AllocsPerOp {
+			regressions = append(regressions, Regression{Baseline: b, Current: r})
+		}
+	}
+	return regressions
+}
+
+// ReadResults reads results saved as JSON.
+func ReadResults(r io.Reader) ([]Result, error) {
+	var results []Result
+	err := json.NewDecoder(r).Decode(&results)
+	return results, err
+}
+
+// WriteResults saves results as JSON.
+func WriteResults(w io.Writer, results []Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
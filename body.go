@@ -0,0 +1,163 @@
+package apierr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"schneider.vip/problem"
+)
+
+// MaxBytesExtension is the problem extension carrying the maximum size of a request body.
+const MaxBytesExtension = "max_bytes"
+
+// Extensions of the JSON decoding problems.
+const (
+	// OffsetExtension is the byte offset of the error in the request body.
+	OffsetExtension = "offset"
+	// FieldExtension is the path of the offending field, e.g. "items.0.quantity".
+	FieldExtension = "field"
+	// ExpectedTypeExtension is the JSON type expected for the field.
+	ExpectedTypeExtension = "expected_type"
+)
+
+// BodyError is a failure reading or decoding the body of a request, replied with the
+// status of the failure: 413 for a body exceeding http.MaxBytesReader, 400 for a
+// truncated (io.ErrUnexpectedEOF), empty (io.EOF, e.g. from json.Decoder.Decode),
+// malformed JSON or otherwise invalid body. Apart from *http.MaxBytesError, which only
+// a request body returns, these errors are replied so only when wrapped in a BodyError,
+// as the same errors returned by e.g. a downstream call are server errors.
+type BodyError struct {
+	// Err is the read or decoding error.
+	Err error
+}
+
+// BodyErr wraps err in a BodyError, nil if err is nil.
+//
+// Example:
+//
+//	if err := xml.NewDecoder(r.Body).Decode(&order); err != nil {
+//		apierr.HandleISE(apierr.BodyErr(err), w)
+//		return
+//	}
+func BodyErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &BodyError{Err: err}
+}
+
+// DecodeJSON decodes the JSON body of r into v. Its errors are BodyError.
+//
+// Example:
+//
+//	var order Order
+//	if err := apierr.DecodeJSON(r, &order); err != nil {
+//		apierr.HandleRequest(err, w, r)
+//		return
+//	}
+func DecodeJSON(r *http.Request, v any) error {
+	return BodyErr(json.NewDecoder(r.Body).Decode(v))
+}
+
+func (e *BodyError) Error() string {
+	return "invalid request body: " + e.Err.Error()
+}
+
+// Unwrap returns the read or decoding error.
+func (e *BodyError) Unwrap() error {
+	return e.Err
+}
+
+// Problem converts the failure to a problem.Problem, see jsonProblem and bodyProblem.
+func (e *BodyError) Problem() *problem.Problem {
+	if p := jsonProblem(e.Err); p != nil {
+		return p
+	}
+	if p := bodyProblem(e.Err); p != nil {
+		return p
+	}
+	return BadRequest.Problem("invalid request body")
+}
+
+// maxBytesProblem returns the 413 problem of a body exceeding http.MaxBytesReader,
+// nil for the other errors.
+func maxBytesProblem(err error) *problem.Problem {
+	var mbe *http.MaxBytesError
+	if !errors.As(err, &mbe) {
+		return nil
+	}
+	return RequestEntityTooLarge.Problem("request body too large").Append(problem.Custom(MaxBytesExtension, mbe.Limit))
+}
+
+// bodyProblem returns the problem of the common request body read failures: 413 for a
+// body exceeding http.MaxBytesReader, 400 for a truncated or empty body.
+// It returns nil for the other errors.
+func bodyProblem(err error) *problem.Problem {
+	if p := maxBytesProblem(err); p != nil {
+		return p
+	}
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return BadRequest.Problem("request body truncated")
+	case errors.Is(err, io.EOF):
+		return BadRequest.Problem("request body is empty")
+	}
+	return nil
+}
+
+// jsonProblem returns the 400 problem of the JSON decoding errors, with the offending
+// offset and field. It returns nil for the other errors.
+func jsonProblem(err error) *problem.Problem {
+	var se *json.SyntaxError
+	var ute *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &se):
+		return BadRequest.Problem("malformed JSON").Append(
+			problem.Detail(se.Error()),
+			problem.Custom(OffsetExtension, se.Offset),
+		)
+	case errors.As(err, &ute):
+		expected := jsonType(ute.Type)
+		p := BadRequest.Problem("invalid JSON value").Append(problem.Custom(OffsetExtension, ute.Offset))
+		if expected != "" {
+			p.Append(problem.Custom(ExpectedTypeExtension, expected))
+		}
+		if ute.Field != "" {
+			p.Append(
+				problem.Detail(fmt.Sprintf("field %s: expected %s, got %s", ute.Field, expected, ute.Value)),
+				problem.Custom(FieldExtension, ute.Field),
+			)
+		}
+		return p
+	}
+	return nil
+}
+
+// jsonType returns the JSON type decoded into t, empty when t is nil or an interface.
+func jsonType(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package apierr_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/debyten/apierr"
+)
+
+func TestHandleBodyErrors(t *testing.T) {
+	decode := func(body string, limit int64) error {
+		r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+		r.Body = http.MaxBytesReader(httptest.NewRecorder(), r.Body, limit)
+		var v struct{}
+		return json.NewDecoder(r.Body).Decode(&v)
+	}
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"max bytes", decode(`{"a": "0123456789"}`, 4), http.StatusRequestEntityTooLarge},
+		{"wrapped max bytes", fmt.Errorf("decode order: %w", decode(`{"a": "0123456789"}`, 4)), http.StatusRequestEntityTooLarge},
+		{"max bytes in BodyError", apierr.BodyErr(decode(`{"a": "0123456789"}`, 4)), http.StatusRequestEntityTooLarge},
+		{"empty body", apierr.BodyErr(decode("", 1024)), http.StatusBadRequest},
+		{"truncated body", apierr.BodyErr(decode(`{"a":`, 1024)), http.StatusBadRequest},
+		{"malformed JSON", apierr.BodyErr(decode(`{"a" 1}`, 1024)), http.StatusBadRequest},
+		{"unwrapped malformed JSON", decode(`{"a" 1}`, 1024), http.StatusInternalServerError},
+		{"bare EOF", io.EOF, http.StatusInternalServerError},
+		{"bare unexpected EOF", io.ErrUnexpectedEOF, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			apierr.NewRegistry().HandleISE(tt.err, w)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONField(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"items": [{"quantity": "two"}]}`))
+	var order struct {
+		Items []struct {
+			Quantity int `json:"quantity"`
+		} `json:"items"`
+	}
+	w := httptest.NewRecorder()
+	apierr.NewRegistry().HandleRequest(apierr.DecodeJSON(r, &order), w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body[apierr.FieldExtension] != "items.0.quantity" || body[apierr.ExpectedTypeExtension] != "number" {
+		t.Errorf("body = %v, want field items.0.quantity expecting a number", body)
+	}
+}
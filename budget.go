@@ -0,0 +1,72 @@
+package apierr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// DeadlineRetryAfter computes the Retry-After delay of a request that exceeded its
+// time budget, given the current load (0 idle, 1 saturated, see Registry.LoadSignal).
+type DeadlineRetryAfter func(budget time.Duration, load float64) time.Duration
+
+// DefaultDeadlineRetryAfter is used for the requests that exceeded their budget (see Budget):
+// the budget, stretched up to three times under full load.
+var DefaultDeadlineRetryAfter DeadlineRetryAfter = func(budget time.Duration, load float64) time.Duration {
+	load = min(max(load, 0), 1)
+	return budget + time.Duration(2*load*float64(budget))
+}
+
+type budgetKey struct{}
+
+// WithBudget returns a copy of ctx bounded by the time budget d of the endpoint.
+// The budget is used to compute the Retry-After when the request fails because of it.
+func WithBudget(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, d)
+	return context.WithValue(ctx, budgetKey{}, d), cancel
+}
+
+// BudgetOf returns the time budget of ctx, see WithBudget.
+func BudgetOf(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(budgetKey{}).(time.Duration)
+	return d, ok
+}
+
+// Budget is a middleware bounding the requests to the time budget d, see WithBudget.
+// The errors caused by the exceeded deadline and handled with HandleRequest/HandleRequestISE
+// get a Retry-After computed from the budget and the load, see DefaultDeadlineRetryAfter.
+//
+// Example:
+//
+//	mux.Handle("/reports", apierr.Budget(2*time.Second)(reports))
+func Budget(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := WithBudget(r.Context(), d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// applyDeadlineRetryAfter sets the Retry-After of the errors caused by an exceeded budget,
+// unless the error already set one.
+func (r *Registry) applyDeadlineRetryAfter(ctx context.Context, w http.ResponseWriter, err error) {
+	if !errors.Is(err, context.DeadlineExceeded) || w.Header().Get("Retry-After") != "" {
+		return
+	}
+	budget, ok := BudgetOf(ctx)
+	if !ok {
+		return
+	}
+	load := 0.0
+	if r.LoadSignal != nil {
+		load = r.LoadSignal()
+	}
+	fn := DefaultDeadlineRetryAfter
+	if r.DeadlineRetryAfter != nil {
+		fn = r.DeadlineRetryAfter
+	}
+	w.Header().Set("Retry-After", retryAfterSeconds(fn(budget, load)))
+}
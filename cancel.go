@@ -0,0 +1,42 @@
+package apierr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"schneider.vip/problem"
+)
+
+// ClientClosedRequest is the non-standard status (from nginx) recorded when the client
+// went away before the response, see HandleRequest.
+const ClientClosedRequest HttpStatus = 499
+
+// DefaultDeadlineProblem returns the problem replied by the request-aware functions for
+// errors caused by context.DeadlineExceeded.
+var DefaultDeadlineProblem = func() *problem.Problem {
+	return GatewayTimeout.Problem(http.StatusText(http.StatusGatewayTimeout))
+}
+
+// contextProblem maps the context errors of err not converted to a problem:
+// context.Canceled, when ctx (the request context) is done, means the client went away,
+// hence nothing is written and the outcome is ClientClosedRequest (done is true);
+// context.DeadlineExceeded is converted to the DeadlineProblem.
+// A context.Canceled of another context (e.g. an operation canceled internally) is
+// left to the usual handling.
+func (r *Registry) contextProblem(ctx context.Context, err error, w http.ResponseWriter, req *http.Request) (p *problem.Problem, done bool) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		if ctx.Err() == nil {
+			return nil, false
+		}
+		r.finish(w, req, err, Outcome{Status: int(ClientClosedRequest), Handled: true})
+		return nil, true
+	case errors.Is(err, context.DeadlineExceeded):
+		if r.DeadlineProblem != nil {
+			return r.DeadlineProblem(), false
+		}
+		return DefaultDeadlineProblem(), false
+	}
+	return nil, false
+}
@@ -0,0 +1,47 @@
+package apierr_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/debyten/apierr"
+)
+
+func TestHandleContextErrors(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want int
+		body bool
+	}{
+		{"deadline", context.TODO(), fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, true},
+		{"client gone", canceled, context.Canceled, http.StatusOK, false},
+		{"canceled internally", context.TODO(), context.Canceled, http.StatusInternalServerError, true},
+	}
+	for _, tt := range tests {
+		t.Run("HandleContextISE "+tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			apierr.NewRegistry().HandleContextISE(tt.ctx, tt.err, w)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			if got := w.Body.Len() > 0; got != tt.body {
+				t.Errorf("body written = %v, want %v", got, tt.body)
+			}
+		})
+		t.Run("HandleRequestISE "+tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(tt.ctx)
+			w := httptest.NewRecorder()
+			apierr.NewRegistry().HandleRequestISE(tt.err, w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package apierr
+
+import (
+	"context"
+	"reflect"
+	"unicode/utf8"
+
+	"schneider.vip/problem"
+)
+
+// CauseChainExtension is the problem extension carrying the rendered cause chain, see CauseChain.
+const CauseChainExtension = "cause_chain"
+
+// TruncatedMarker terminates a cause chain or a cause message cut by the CauseChainLimits.
+const TruncatedMarker = "…[truncated]"
+
+// CauseChainLimits bounds the cause chain rendered by CauseChain, so that a pathological
+// error (cycles, enormous wrapped messages) cannot produce huge responses.
+type CauseChainLimits struct {
+	// MaxDepth is the maximum number of causes rendered.
+	MaxDepth int
+	// MaxBytes is the maximum total size of the rendered messages.
+	MaxBytes int
+}
+
+// DefaultCauseChainLimits are the limits used when a CauseChainLimits field is not positive.
+var DefaultCauseChainLimits = CauseChainLimits{MaxDepth: 16, MaxBytes: 8 << 10}
+
+// CauseChain returns a Decorator rendering the chain of the handled error in the
+// CauseChainExtension, one message per cause. It is meant for debugging, since the messages
+// are not sanitized: register it only in development environments.
+//
+// Example:
+//
+//	if debug {
+//		apierr.AddDecorator(apierr.CauseChain(apierr.CauseChainLimits{MaxDepth: 8}))
+//	}
+func CauseChain(limits CauseChainLimits) Decorator {
+	return defaultRegistry.CauseChain(limits)
+}
+
+// CauseChain is the Registry version of the package-level CauseChain: the chain
+// is walked with the unwrappers of r.
+func (r *Registry) CauseChain(limits CauseChainLimits) Decorator {
+	if limits.MaxDepth <= 0 {
+		limits.MaxDepth = DefaultCauseChainLimits.MaxDepth
+	}
+	if limits.MaxBytes <= 0 {
+		limits.MaxBytes = DefaultCauseChainLimits.MaxBytes
+	}
+	return func(_ context.Context, err error, p *problem.Problem) {
+		p.Append(problem.Custom(CauseChainExtension, r.renderCauses(err, limits)))
+	}
+}
+
+func (r *Registry) renderCauses(err error, limits CauseChainLimits) []string {
+	var causes []string
+	seen := map[error]bool{}
+	budget := limits.MaxBytes
+	for depth := 0; err != nil; depth, err = depth+1, r.unwrap(err) {
+		if len(causes) == limits.MaxDepth || depth == maxTreeDepth || budget <= 0 {
+			return append(causes, TruncatedMarker)
+		}
+		// a comparable type can hold an unhashable value, e.g. a slice in an interface field
+		if reflect.ValueOf(err).Comparable() {
+			if seen[err] {
+				return append(causes, TruncatedMarker)
+			}
+			seen[err] = true
+		}
+		msg := err.Error()
+		if len(msg) > budget {
+			msg = truncateUTF8(msg, budget) + TruncatedMarker
+		}
+		budget -= len(msg)
+		causes = append(causes, msg)
+	}
+	return causes
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
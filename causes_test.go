@@ -0,0 +1,52 @@
+package apierr_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/debyten/apierr"
+	"schneider.vip/problem"
+)
+
+// detailsError is comparable by type, but holds an unhashable value.
+type detailsError struct{ details any }
+
+func (e detailsError) Error() string { return fmt.Sprint("details ", e.details) }
+
+func TestCauseChain(t *testing.T) {
+	long := strings.Repeat("é", 20)
+	cyclic := &cyclicError{}
+	cyclic.next = cyclic
+	tests := []struct {
+		name   string
+		err    error
+		limits apierr.CauseChainLimits
+		want   []string
+	}{
+		{"chain", fmt.Errorf("save: %w", errors.New("disk full")), apierr.CauseChainLimits{}, []string{"save: disk full", "disk full"}},
+		{"unhashable value", fmt.Errorf("save: %w", detailsError{[]string{"a"}}), apierr.CauseChainLimits{}, []string{"save: details [a]", "details [a]"}},
+		{"cycle", cyclic, apierr.CauseChainLimits{}, []string{"cyclic", apierr.TruncatedMarker}},
+		{"max depth", fmt.Errorf("a: %w", fmt.Errorf("b: %w", errors.New("c"))), apierr.CauseChainLimits{MaxDepth: 2}, []string{"a: b: c", "b: c", apierr.TruncatedMarker}},
+		{"max bytes", errors.New(long), apierr.CauseChainLimits{MaxBytes: 5}, []string{"éé" + apierr.TruncatedMarker}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := problem.Of(500)
+			apierr.NewRegistry().CauseChain(tt.limits)(context.Background(), tt.err, p)
+			var body struct {
+				Causes []string `json:"cause_chain"`
+			}
+			if err := json.Unmarshal(p.JSON(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(body.Causes, tt.want) {
+				t.Errorf("causes = %q, want %q", body.Causes, tt.want)
+			}
+		})
+	}
+}
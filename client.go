@@ -0,0 +1,124 @@
+package apierr
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"schneider.vip/problem"
+)
+
+// maxProblemBody is the maximum size of the problem bodies read by FromResponse.
+const maxProblemBody = 1 << 20
+
+// FromResponse converts an error response of a service using apierr back to an *APIErr,
+// so that the status code, the application code and the extras are preserved across hops.
+// The members of an application/problem+json body are kept as well and written again
+// if the error is handled.
+//
+// The upstream trace id, if any, is available with UpstreamTraceID.
+//
+// It returns nil when resp is not an error (status < 400). The body is consumed.
+//
+// Example:
+//
+//	resp, err := client.Do(req)
+//	if err != nil {
+//		return err
+//	}
+//	defer resp.Body.Close()
+//	if err := apierr.FromResponse(resp); err != nil {
+//		return err
+//	}
+func FromResponse(resp *http.Response) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	e := &APIErr{status: HttpStatus(resp.StatusCode)}
+	text := http.StatusText(resp.StatusCode)
+	if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt == problem.ContentTypeJSON {
+		data := map[string]any{}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, maxProblemBody)).Decode(&data); err == nil {
+			if s, ok := data["detail"].(string); ok {
+				text = s
+			} else if s, ok := data["title"].(string); ok {
+				text = s
+			}
+			for k, v := range data {
+				if k == "status" || k == "detail" {
+					continue
+				}
+				e.opts = append(e.opts, problem.Custom(k, v))
+			}
+		}
+	}
+	e.err = errors.New(text)
+	if code := resp.Header.Get(AppErrorHeader); code != "" {
+		e.code = strings.TrimPrefix(code, Prefix+".")
+	}
+	for k, v := range resp.Header {
+		if key, ok := strings.CutPrefix(k, AppErrorHeader+"-"); ok && len(v) > 0 {
+			e.WithExtra(key, v[0])
+		}
+	}
+	e.upstreamTrace = traceID(resp.Header)
+	return e
+}
+
+// UpstreamTraceID returns the trace id of the failing response the error was created
+// from by FromResponse, empty if the response carried none.
+func (e *APIErr) UpstreamTraceID() string {
+	return e.upstreamTrace
+}
+
+// UpstreamTraceID returns the upstream trace id of the first APIErr found in the chain
+// of err, see APIErr.UpstreamTraceID. Log it next to the local trace id to correlate
+// the failure across services.
+//
+// Example:
+//
+//	if err := apierr.FromResponse(resp); err != nil {
+//		logger.Error("billing call failed", "err", err, "upstream_trace_id", apierr.UpstreamTraceID(err))
+//		return err
+//	}
+func UpstreamTraceID(err error) string {
+	var e *APIErr
+	if errors.As(err, &e) {
+		return e.upstreamTrace
+	}
+	return ""
+}
+
+// traceIDHeaders are the trace id headers read by FromResponse when the response
+// has no valid W3C traceparent header.
+var traceIDHeaders = []string{"X-Trace-Id", "X-B3-TraceId", "X-Cloud-Trace-Context"}
+
+// traceID returns the trace id carried by h: the trace-id field of the W3C traceparent
+// header, otherwise the first of traceIDHeaders set.
+func traceID(h http.Header) string {
+	// version "-" trace-id "-" parent-id "-" trace-flags
+	parts := strings.Split(h.Get("Traceparent"), "-")
+	if len(parts) >= 4 && len(parts[1]) == 32 && isHex(parts[1]) && parts[1] != strings.Repeat("0", 32) {
+		return parts[1]
+	}
+	for _, name := range traceIDHeaders {
+		if v := h.Get(name); v != "" {
+			// X-Cloud-Trace-Context is TRACE_ID/SPAN_ID;o=OPTIONS
+			id, _, _ := strings.Cut(v, "/")
+			return id
+		}
+	}
+	return ""
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
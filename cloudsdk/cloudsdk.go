@@ -0,0 +1,104 @@
+// Package cloudsdk maps the errors of the cloud SDKs (AWS SDK for Go v2, Google Cloud
+// client libraries) to problems, so that services proxying cloud resources do not reply
+// provider-specific failures as 500s.
+//
+// The errors are recognized by their methods, hence this package does not depend on the SDKs.
+// The provider messages are not exposed to the clients.
+//
+// Example:
+//
+//	if err := apierr.RegisterHandlers(cloudsdk.Handlers); err != nil {
+//		log.Fatal(err)
+//	}
+package cloudsdk
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/debyten/apierr"
+	"schneider.vip/problem"
+)
+
+// Handlers are the handlers of the package, to be registered with apierr.RegisterHandlers.
+var Handlers = []apierr.NamedHandler{
+	{Name: "cloudsdk.aws", Handler: AWS},
+	{Name: "cloudsdk.gcp", Handler: GCP},
+}
+
+// awsCodes maps the AWS error codes to statuses.
+var awsCodes = map[string]apierr.HttpStatus{
+	"NoSuchKey":                              apierr.NotFound,
+	"NoSuchBucket":                           apierr.NotFound,
+	"NotFound":                               apierr.NotFound,
+	"ResourceNotFoundException":              apierr.NotFound,
+	"AccessDenied":                           apierr.Forbidden,
+	"AccessDeniedException":                  apierr.Forbidden,
+	"UnauthorizedOperation":                  apierr.Forbidden,
+	"Throttling":                             apierr.TooManyRequests,
+	"ThrottlingException":                    apierr.TooManyRequests,
+	"TooManyRequestsException":               apierr.TooManyRequests,
+	"RequestLimitExceeded":                   apierr.TooManyRequests,
+	"SlowDown":                               apierr.TooManyRequests,
+	"ProvisionedThroughputExceededException": apierr.TooManyRequests,
+}
+
+// AWS maps the AWS SDK errors (smithy.APIError) by error code:
+// missing keys and resources to 404, access denied to 403 and throttling to 429.
+func AWS(err error) *problem.Problem {
+	var apiErr interface {
+		error
+		ErrorCode() string
+	}
+	if !errors.As(err, &apiErr) {
+		return nil
+	}
+	status, ok := awsCodes[apiErr.ErrorCode()]
+	if !ok {
+		return nil
+	}
+	return mapped(status)
+}
+
+// GCP maps the Google Cloud errors (apierror.APIError and googleapi.Error) by
+// HTTP status: 404, 403 and 429 are preserved.
+func GCP(err error) *problem.Problem {
+	code := 0
+	var apiErr interface {
+		error
+		HTTPCode() int
+	}
+	if errors.As(err, &apiErr) {
+		code = apiErr.HTTPCode()
+	} else {
+		code = googleAPICode(err)
+	}
+	switch code {
+	case http.StatusNotFound, http.StatusForbidden, http.StatusTooManyRequests:
+		return mapped(apierr.HttpStatus(code))
+	}
+	return nil
+}
+
+// googleAPICode returns the Code field of the *googleapi.Error found in the chain of err, 0 if none.
+func googleAPICode(err error) int {
+	for ; err != nil; err = errors.Unwrap(err) {
+		v := reflect.ValueOf(err)
+		if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+			continue
+		}
+		v = v.Elem()
+		if v.Type().PkgPath() != "google.golang.org/api/googleapi" {
+			continue
+		}
+		if f := v.FieldByName("Code"); f.IsValid() && f.Kind() == reflect.Int {
+			return int(f.Int())
+		}
+	}
+	return 0
+}
+
+func mapped(status apierr.HttpStatus) *problem.Problem {
+	return status.Problem(http.StatusText(int(status)))
+}
@@ -0,0 +1,62 @@
+// Command apierrbench runs the error path benchmarks and fails when they regress
+// with respect to a baseline.
+//
+// Usage:
+//
+//	apierrbench -update              # record the baseline
+//	apierrbench                      # compare with the baseline
+//	apierrbench -benchfmt > new.txt  # print results for benchstat
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/debyten/apierr/benchmarks"
+)
+
+func main() {
+	baseline := flag.String("baseline", "bench_baseline.json", "baseline file")
+	update := flag.Bool("update", false, "record the results as the new baseline")
+	threshold := flag.Float64("threshold", 0.15, "tolerated slowdown ratio")
+	benchfmt := flag.Bool("benchfmt", false, "print the results in the Go benchmark format and exit")
+	flag.Parse()
+
+	results := benchmarks.Run()
+	if *benchfmt {
+		if err := benchmarks.WriteBenchfmt(os.Stdout, results); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *update {
+		f, err := os.Create(*baseline)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		if err := benchmarks.WriteResults(f, results); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.Open(*baseline)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	base, err := benchmarks.ReadResults(f)
+	if err != nil {
+		log.Fatal(err)
+	}
+	regressions := benchmarks.Compare(base, results, *threshold)
+	for _, r := range regressions {
+		fmt.Println(r)
+	}
+	if len(regressions) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,113 @@
+// Command apierrgen generates typed constructors from a catalog of error codes, so that
+// the error definitions of a service are checked at compile time.
+//
+// The catalog is a JSON file:
+//
+//	{
+//		"codes": [
+//			{"code": "USER_NOT_FOUND", "status": 404, "title": "user not found"},
+//			{"code": "QUOTA_EXCEEDED", "status": 429, "title": "quota exceeded", "doc": "The monthly quota is exhausted."}
+//		]
+//	}
+//
+// Each code is registered with apierr.Register and gets a constructor named after
+// it, e.g. UserNotFound(err error) *apierr.APIErr.
+//
+// Usage:
+//
+//	//go:generate go run github.com/debyten/apierr/cmd/apierrgen -in errors.json -out errors_gen.go -package errs
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"unicode"
+)
+
+type catalog struct {
+	Codes []entry `json:"codes"`
+}
+
+type entry struct {
+	Code   string `json:"code"`
+	Status int    `json:"status"`
+	Title  string `json:"title"`
+	Doc    string `json:"doc"`
+}
+
+func main() {
+	in := flag.String("in", "errors.json", "catalog file")
+	out := flag.String("out", "errors_gen.go", "generated file")
+	pkg := flag.String("package", os.Getenv("GOPACKAGE"), "package of the generated file")
+	flag.Parse()
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var c catalog
+	if err := json.Unmarshal(data, &c); err != nil {
+		log.Fatalf("%s: %v", *in, err)
+	}
+	if *pkg == "" {
+		log.Fatal("missing -package")
+	}
+	src, err := generate(*pkg, c)
+	if err != nil {
+		log.Fatalf("%s: %v", *in, err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generate(pkg string, c catalog) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by apierrgen. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	fmt.Fprintf(&buf, "import \"github.com/debyten/apierr\"\n\n")
+	names := map[string]string{}
+	for _, e := range c.Codes {
+		name := goName(e.Code)
+		switch {
+		case name == "":
+			return nil, fmt.Errorf("invalid code %q", e.Code)
+		case names[name] != "":
+			return nil, fmt.Errorf("codes %q and %q have the same constructor name %s", names[name], e.Code, name)
+		case http.StatusText(e.Status) == "" || e.Status < 400:
+			return nil, fmt.Errorf("code %q: invalid error status %d", e.Code, e.Status)
+		}
+		names[name] = e.Code
+		fmt.Fprintf(&buf, "// Code%s is the %s error code.\n", name, e.Code)
+		fmt.Fprintf(&buf, "var Code%s = apierr.Register(%q, %d, %q)\n\n", name, e.Code, e.Status, e.Title)
+		fmt.Fprintf(&buf, "// %s returns a %d %s error wrapping err.\n", name, e.Status, e.Code)
+		if e.Doc != "" {
+			fmt.Fprintf(&buf, "//\n// %s\n", strings.ReplaceAll(e.Doc, "\n", "\n// "))
+		}
+		fmt.Fprintf(&buf, "func %s(err error) *apierr.APIErr {\n\treturn Code%s.Err(err)\n}\n\n", name, name)
+	}
+	return format.Source(buf.Bytes())
+}
+
+// goName converts a code (USER_NOT_FOUND, user-not-found...) to an exported identifier (UserNotFound).
+func goName(code string) string {
+	var sb strings.Builder
+	for _, word := range strings.FieldsFunc(code, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		r := []rune(strings.ToLower(word))
+		r[0] = unicode.ToUpper(r[0])
+		sb.WriteString(string(r))
+	}
+	name := sb.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		return ""
+	}
+	return name
+}
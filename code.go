@@ -0,0 +1,176 @@
+package apierr
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"schneider.vip/problem"
+)
+
+// Code is a stable application error code registered in the catalog, see Register.
+type Code struct {
+	// ID is the code, e.g. "USER_NOT_FOUND". It is sent in the AppErrorHeader and
+	// it is the last segment of the problem type URI.
+	ID     string
+	Status HttpStatus
+	Title  string
+	// DocsQuery are the documentation search terms of the code, sent in the
+	// DocsQueryExtension when Registry.DocsQuery is enabled. Register computes
+	// them from the ID and title, see WithDocsQuery.
+	DocsQuery string
+
+	// registry is the registry of the catalog of the code, nil for the default one.
+	registry *Registry
+}
+
+// codeCatalog is the catalog of the codes of a Registry.
+type codeCatalog struct {
+	codes       map[string]*Code
+	ids         []string
+	conflicts   []string
+	typeBaseURL string
+}
+
+// Register registers a code in the catalog of the default registry, see Registry.Register.
+//
+// Example:
+//
+//	var ErrUserNotFound = apierr.Register("USER_NOT_FOUND", apierr.NotFound, "user not found")
+//
+//	func (s *Users) Find(ctx context.Context, id string) (*User, error) {
+//		// ...
+//		return nil, ErrUserNotFound.Err(err)
+//	}
+func Register(id string, status HttpStatus, title string) *Code {
+	return defaultRegistry.Register(id, status, title)
+}
+
+// Register registers a code in the catalog of r and returns it, to be used as constructor
+// of the errors of that code. The problems of these errors have the code title and the
+// type URI <base>/errors/<id>, see SetTypeBaseURL.
+func (r *Registry) Register(id string, status HttpStatus, title string) *Code {
+	if _, ok := r.catalog.codes[id]; ok {
+		r.catalog.conflicts = append(r.catalog.conflicts, id)
+	} else {
+		r.catalog.ids = append(r.catalog.ids, id)
+	}
+	c := &Code{ID: id, Status: status, Title: title, DocsQuery: docsQuery(id, title), registry: r}
+	if r.catalog.codes == nil {
+		r.catalog.codes = map[string]*Code{}
+	}
+	r.catalog.codes[id] = c
+	return c
+}
+
+// SetTypeBaseURL sets the base of the type URIs of the codes of the default registry,
+// see Registry.SetTypeBaseURL.
+func SetTypeBaseURL(base string) {
+	defaultRegistry.SetTypeBaseURL(base)
+}
+
+// SetTypeBaseURL sets the base of the problem type URIs of the codes of r,
+// e.g. "https://api.example.com". When not set, type URIs are relative: /errors/<id>.
+func (r *Registry) SetTypeBaseURL(base string) {
+	r.catalog.typeBaseURL = strings.TrimSuffix(base, "/")
+}
+
+// LookupCode returns the code id of the default registry.
+func LookupCode(id string) (*Code, bool) {
+	return defaultRegistry.LookupCode(id)
+}
+
+// LookupCode returns the code id registered in r.
+func (r *Registry) LookupCode(id string) (*Code, bool) {
+	c, ok := r.catalog.codes[id]
+	return c, ok
+}
+
+// Codes returns the codes of the default registry, in registration order.
+func Codes() []*Code {
+	return defaultRegistry.Codes()
+}
+
+// Codes returns the codes registered in r, in registration order.
+func (r *Registry) Codes() []*Code {
+	cs := make([]*Code, 0, len(r.catalog.ids))
+	for _, id := range r.catalog.ids {
+		cs = append(cs, r.catalog.codes[id])
+	}
+	return cs
+}
+
+// Err creates an APIErr of code c wrapping err, see New.
+func (c *Code) Err(err error) *APIErr {
+	e := newAPIErr(1, c.Status, err).WithCode(c.ID)
+	e.def = c
+	return e
+}
+
+// Text creates an APIErr of code c with the given text as problem detail, see FromText.
+func (c *Code) Text(text string) *APIErr {
+	e := newAPIErr(1, c.Status, errors.New(text)).WithCode(c.ID)
+	e.def = c
+	return e
+}
+
+// TypeURI returns the problem type URI of c.
+func (c *Code) TypeURI() string {
+	return c.reg().catalog.typeBaseURL + "/errors/" + url.PathEscape(c.ID)
+}
+
+// reg returns the registry of c, the default one for the codes not created by Register.
+func (c *Code) reg() *Registry {
+	if c.registry == nil {
+		return defaultRegistry
+	}
+	return c.registry
+}
+
+// options returns the problem options of c.
+func (c *Code) options() []problem.Option {
+	return []problem.Option{problem.Type(c.TypeURI()), problem.Title(c.Title)}
+}
+
+// codeOf returns the code of e: the one it was created with (see Code.Err) or else
+// the one of its ID (see APIErr.WithCode) in the catalog of r.
+func (r *Registry) codeOf(e *APIErr) (*Code, bool) {
+	if e.def != nil {
+		return e.def, true
+	}
+	if e.code == "" {
+		return nil, false
+	}
+	return r.LookupCode(e.code)
+}
+
+// codeID returns the ID of the code of the type URI typ in the catalog of r.
+func (r *Registry) codeID(typ string) (string, bool) {
+	if r.catalog.typeBaseURL == "" {
+		return "", false
+	}
+	id, ok := strings.CutPrefix(typ, r.catalog.typeBaseURL+"/errors/")
+	if !ok {
+		return "", false
+	}
+	id, err := url.PathUnescape(id)
+	return id, err == nil
+}
+
+// validateCodes reports the codes registered more than once and an invalid type base URL.
+func (r *Registry) validateCodes() []error {
+	var errs []error
+	conflicts := append([]string(nil), r.catalog.conflicts...)
+	sort.Strings(conflicts)
+	for _, id := range conflicts {
+		errs = append(errs, fmt.Errorf("code %q registered more than once", id))
+	}
+	if base := r.catalog.typeBaseURL; base != "" {
+		if u, err := url.Parse(base); err != nil || !u.IsAbs() {
+			errs = append(errs, fmt.Errorf("type base URL %q is not an absolute URL", base))
+		}
+	}
+	return errs
+}
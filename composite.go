@@ -0,0 +1,125 @@
+package apierr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"schneider.vip/problem"
+)
+
+// StepsExtension is the problem extension listing the steps of a CompositeError.
+const StepsExtension = "steps"
+
+// Step is the outcome of a step of a composite operation.
+type Step struct {
+	Name   string `json:"name"`
+	Failed bool   `json:"failed"`
+	// Status is the status of the problem of the failed step, 500 when its error is not
+	// convertible to a problem.
+	Status int `json:"status,omitempty"`
+}
+
+// CompositeError is a builder recording the outcome of each step of an operation
+// orchestrating several downstream calls.
+//
+// When the failed steps carry a client error problem (4xx) the request itself is at
+// fault, and the one with the highest status is replied. Otherwise a dependency failed
+// and a 502 is replied, with the StepsExtension listing the outcome of every step.
+//
+// Example:
+//
+//	c := apierr.Composite()
+//	c.Step("inventory", inventory.Reserve(ctx, order))
+//	c.Step("payments", payments.Charge(ctx, order))
+//	if err := c.Err(); err != nil {
+//		return err
+//	}
+type CompositeError struct {
+	registry *Registry
+	steps    []Step
+	errs     []error
+}
+
+// Composite returns an empty CompositeError, converting the errors of the steps to
+// problems with the default registry.
+func Composite() *CompositeError {
+	return defaultRegistry.Composite()
+}
+
+// Composite returns an empty CompositeError, converting the errors of the steps to
+// problems with the handlers and unwrappers of r.
+func (r *Registry) Composite() *CompositeError {
+	return &CompositeError{registry: r}
+}
+
+// reg returns the registry of c, the default one for a zero CompositeError.
+func (c *CompositeError) reg() *Registry {
+	if c.registry == nil {
+		return defaultRegistry
+	}
+	return c.registry
+}
+
+// Step records the outcome of the step name: it failed when err is not nil.
+func (c *CompositeError) Step(name string, err error) *CompositeError {
+	s := Step{Name: name}
+	if err != nil {
+		s.Failed = true
+		s.Status = http.StatusInternalServerError
+		if p, _ := c.reg().extractProblem(err); p != nil {
+			s.Status = problemStatus(p)
+		}
+		c.errs = append(c.errs, err)
+	}
+	c.steps = append(c.steps, s)
+	return c
+}
+
+// Steps returns the steps recorded so far.
+func (c *CompositeError) Steps() []Step {
+	return c.steps
+}
+
+// Err returns c if a step failed, nil otherwise.
+func (c *CompositeError) Err() error {
+	if len(c.errs) == 0 {
+		return nil
+	}
+	return c
+}
+
+func (c *CompositeError) Error() string {
+	var failed []string
+	for _, s := range c.steps {
+		if s.Failed {
+			failed = append(failed, s.Name)
+		}
+	}
+	return fmt.Sprintf("%s failed: %v", strings.Join(failed, ", "), errors.Join(c.errs...))
+}
+
+// Unwrap returns the errors of the failed steps.
+func (c *CompositeError) Unwrap() []error {
+	return c.errs
+}
+
+// Problem returns the dominant client error problem of the failed steps, if any,
+// or a 502 problem with the StepsExtension.
+func (c *CompositeError) Problem() *problem.Problem {
+	var dominant *problem.Problem
+	for _, err := range c.errs {
+		p, _ := c.reg().extractProblem(err)
+		if p == nil {
+			continue
+		}
+		if s := problemStatus(p); s >= 400 && s < 500 && (dominant == nil || s > problemStatus(dominant)) {
+			dominant = p
+		}
+	}
+	if dominant != nil {
+		return dominant
+	}
+	return BadGateway.Problem("a dependency failed").Append(problem.Custom(StepsExtension, c.steps))
+}
@@ -0,0 +1,173 @@
+package apierr
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NotModified is the status replied to a conditional GET or HEAD whose
+// representation did not change, see CheckPreconditions.
+const NotModified HttpStatus = 304
+
+// CheckPreconditions evaluates the conditional headers of r (If-Match, If-Unmodified-Since,
+// If-None-Match, If-Modified-Since) against the current representation of the resource,
+// in the order of RFC 9110 section 13.2.2. It returns:
+//
+//   - nil when the request must be performed;
+//   - a NotModified error, carrying the ETag and Last-Modified headers, for a GET or
+//     HEAD whose representation did not change;
+//   - a PreconditionFailed error otherwise.
+//
+// etag is the entity tag including quotes (e.g. `"v42"` or `W/"v42"`), empty if none;
+// lastModified is zero if unknown.
+//
+// Example:
+//
+//	if err := apierr.CheckPreconditions(r, obj.ETag, obj.ModTime); err != nil {
+//		apierr.HandleRequest(err, w, r)
+//		return
+//	}
+func CheckPreconditions(r *http.Request, etag string, lastModified time.Time) error {
+	if im := r.Header.Get("If-Match"); im != "" {
+		if !matchETag(im, etag, false) {
+			return preconditionFailed("If-Match")
+		}
+	} else if t, ok := headerTime(r, "If-Unmodified-Since"); ok && !lastModified.IsZero() {
+		if lastModified.Truncate(time.Second).After(t) {
+			return preconditionFailed("If-Unmodified-Since")
+		}
+	}
+	safe := r.Method == http.MethodGet || r.Method == http.MethodHead
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if matchETag(inm, etag, true) {
+			if safe {
+				return notModified(etag, lastModified)
+			}
+			return preconditionFailed("If-None-Match")
+		}
+	} else if t, ok := headerTime(r, "If-Modified-Since"); ok && safe && !lastModified.IsZero() {
+		if !lastModified.Truncate(time.Second).After(t) {
+			return notModified(etag, lastModified)
+		}
+	}
+	return nil
+}
+
+// CheckRange validates the Range header of r against a representation of size bytes.
+// It returns nil when r has no byte Range header, when the range is ignored because of
+// a failed If-Range (the full representation must be sent), or when at least one range
+// is satisfiable; otherwise it returns RangeNotSatisfiable(size).
+//
+// Call it after CheckPreconditions, with the same etag and lastModified.
+func CheckRange(r *http.Request, etag string, lastModified time.Time, size int64) error {
+	rng := r.Header.Get("Range")
+	if rng == "" || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return nil
+	}
+	if ir := r.Header.Get("If-Range"); ir != "" && !ifRange(ir, etag, lastModified) {
+		return nil
+	}
+	specs, ok := strings.CutPrefix(rng, "bytes=")
+	if !ok {
+		// unknown range units are ignored
+		return nil
+	}
+	for _, spec := range strings.Split(specs, ",") {
+		first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+		if !ok {
+			continue
+		}
+		if first == "" {
+			// suffix range: the last n bytes
+			if n, err := strconv.ParseInt(last, 10, 64); err == nil && n > 0 && size > 0 {
+				return nil
+			}
+			continue
+		}
+		start, err := strconv.ParseInt(first, 10, 64)
+		if err != nil {
+			continue
+		}
+		if last != "" {
+			if end, err := strconv.ParseInt(last, 10, 64); err != nil || end < start {
+				continue
+			}
+		}
+		if start < size {
+			return nil
+		}
+	}
+	return rangeNotSatisfiable(size)
+}
+
+// RangeNotSatisfiable returns the 416 error for a representation of size bytes,
+// carrying the Content-Range: bytes */size header required by RFC 9110.
+func RangeNotSatisfiable(size int64) *APIErr {
+	return rangeNotSatisfiable(size)
+}
+
+// The helpers below must be called directly by the exported functions, see newAPIErr.
+
+func rangeNotSatisfiable(size int64) *APIErr {
+	return newAPIErr(2, RequestedRangeNotSatisfiable, errors.New("range not satisfiable")).
+		WithHeader("Content-Range", "bytes */"+strconv.FormatInt(size, 10))
+}
+
+func preconditionFailed(header string) *APIErr {
+	return newAPIErr(2, PreconditionFailed, errors.New(header+" precondition failed"))
+}
+
+func notModified(etag string, lastModified time.Time) *APIErr {
+	e := newAPIErr(2, NotModified, nil)
+	if etag != "" {
+		e.WithHeader("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		e.WithHeader("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	return e
+}
+
+// matchETag reports whether etag matches the list of entity tags of a conditional
+// header, with the weak comparison for If-None-Match and the strong one for If-Match.
+func matchETag(list, etag string, weak bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return etag != ""
+	}
+	if etag == "" || (!weak && strings.HasPrefix(etag, "W/")) {
+		return false
+	}
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		} else if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifRange reports whether the If-Range validator matches the representation:
+// an entity tag must match strongly, a date exactly.
+func ifRange(validator, etag string, lastModified time.Time) bool {
+	if strings.HasPrefix(validator, `"`) || strings.HasPrefix(validator, "W/") {
+		return !strings.HasPrefix(validator, "W/") && matchETag(validator, etag, false)
+	}
+	t, err := http.ParseTime(validator)
+	return err == nil && !lastModified.IsZero() && lastModified.Truncate(time.Second).Equal(t)
+}
+
+func headerTime(r *http.Request, name string) (time.Time, bool) {
+	v := r.Header.Get(name)
+	if v == "" {
+		return time.Time{}, false
+	}
+	t, err := http.ParseTime(v)
+	return t, err == nil
+}
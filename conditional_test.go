@@ -0,0 +1,185 @@
+package apierr_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/debyten/apierr"
+)
+
+var modTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// status returns the status of err, 0 when nil.
+func status(t *testing.T, err error) int {
+	t.Helper()
+	if err == nil {
+		return 0
+	}
+	var e *apierr.APIErr
+	if !errors.As(err, &e) {
+		t.Fatalf("error %v is not an APIErr", err)
+	}
+	return e.StatusCode()
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	before := modTime.Add(-time.Hour).Format(http.TimeFormat)
+	after := modTime.Add(time.Hour).Format(http.TimeFormat)
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		etag    string
+		want    int
+	}{
+		{"no conditions", http.MethodGet, nil, `"v1"`, 0},
+
+		// step 1: If-Match, strong comparison
+		{"If-Match matching", http.MethodPut, map[string]string{"If-Match": `"v1"`}, `"v1"`, 0},
+		{"If-Match in a list", http.MethodPut, map[string]string{"If-Match": `"v0", "v1"`}, `"v1"`, 0},
+		{"If-Match not matching", http.MethodPut, map[string]string{"If-Match": `"v0"`}, `"v1"`, 412},
+		{"If-Match weak candidate", http.MethodPut, map[string]string{"If-Match": `W/"v1"`}, `"v1"`, 412},
+		{"If-Match weak etag", http.MethodPut, map[string]string{"If-Match": `W/"v1"`}, `W/"v1"`, 412},
+		{"If-Match any", http.MethodPut, map[string]string{"If-Match": "*"}, `"v1"`, 0},
+		{"If-Match any without representation", http.MethodPut, map[string]string{"If-Match": "*"}, "", 412},
+
+		// step 2: If-Unmodified-Since, only without If-Match
+		{"If-Unmodified-Since after", http.MethodPut, map[string]string{"If-Unmodified-Since": after}, `"v1"`, 0},
+		{"If-Unmodified-Since before", http.MethodPut, map[string]string{"If-Unmodified-Since": before}, `"v1"`, 412},
+		{"If-Unmodified-Since ignored with If-Match", http.MethodPut, map[string]string{
+			"If-Match": `"v1"`, "If-Unmodified-Since": before,
+		}, `"v1"`, 0},
+
+		// step 3: If-None-Match, weak comparison
+		{"If-None-Match GET", http.MethodGet, map[string]string{"If-None-Match": `"v1"`}, `"v1"`, 304},
+		{"If-None-Match HEAD", http.MethodHead, map[string]string{"If-None-Match": `"v1"`}, `"v1"`, 304},
+		{"If-None-Match weak candidate", http.MethodGet, map[string]string{"If-None-Match": `W/"v1"`}, `"v1"`, 304},
+		{"If-None-Match weak etag", http.MethodGet, map[string]string{"If-None-Match": `"v1"`}, `W/"v1"`, 304},
+		{"If-None-Match not matching", http.MethodGet, map[string]string{"If-None-Match": `"v0"`}, `"v1"`, 0},
+		{"If-None-Match PUT", http.MethodPut, map[string]string{"If-None-Match": `"v1"`}, `"v1"`, 412},
+		{"If-None-Match any PUT", http.MethodPut, map[string]string{"If-None-Match": "*"}, `"v1"`, 412},
+		{"If-None-Match any PUT without representation", http.MethodPut, map[string]string{"If-None-Match": "*"}, "", 0},
+		{"If-Match evaluated first", http.MethodGet, map[string]string{
+			"If-Match": `"v0"`, "If-None-Match": `"v1"`,
+		}, `"v1"`, 412},
+
+		// step 4: If-Modified-Since, only for GET and HEAD without If-None-Match
+		{"If-Modified-Since after", http.MethodGet, map[string]string{"If-Modified-Since": after}, `"v1"`, 304},
+		{"If-Modified-Since before", http.MethodGet, map[string]string{"If-Modified-Since": before}, `"v1"`, 0},
+		{"If-Modified-Since POST", http.MethodPost, map[string]string{"If-Modified-Since": after}, `"v1"`, 0},
+		{"If-Modified-Since ignored with If-None-Match", http.MethodGet, map[string]string{
+			"If-None-Match": `"v0"`, "If-Modified-Since": after,
+		}, `"v1"`, 0},
+		{"If-Modified-Since invalid", http.MethodGet, map[string]string{"If-Modified-Since": "yesterday"}, `"v1"`, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/obj", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := status(t, apierr.CheckPreconditions(r, tt.etag, modTime)); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPreconditionsNotModifiedHasNoBody(t *testing.T) {
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		t.Run(method, func(t *testing.T) {
+			r := httptest.NewRequest(method, "/obj", nil)
+			r.Header.Set("If-None-Match", `"v1"`)
+			err := apierr.CheckPreconditions(r, `"v1"`, modTime)
+			w := httptest.NewRecorder()
+			if !apierr.NewRegistry().HandleRequest(err, w, r) {
+				t.Fatal("error not handled")
+			}
+			if w.Code != http.StatusNotModified {
+				t.Errorf("status = %d, want 304", w.Code)
+			}
+			if w.Body.Len() != 0 {
+				t.Errorf("body = %q, want none", w.Body.String())
+			}
+			if got := w.Header().Get("Content-Type"); got != "" {
+				t.Errorf("Content-Type = %q, want none", got)
+			}
+			if got := w.Header().Get("ETag"); got != `"v1"` {
+				t.Errorf("ETag = %q, want %q", got, `"v1"`)
+			}
+			if got := w.Header().Get("Last-Modified"); got != modTime.Format(http.TimeFormat) {
+				t.Errorf("Last-Modified = %q, want %q", got, modTime.Format(http.TimeFormat))
+			}
+		})
+	}
+}
+
+func TestCheckRange(t *testing.T) {
+	const size = 100
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		etag    string
+		want    int
+	}{
+		{"no range", http.MethodGet, nil, `"v1"`, 0},
+		{"satisfiable", http.MethodGet, map[string]string{"Range": "bytes=0-49"}, `"v1"`, 0},
+		{"open ended", http.MethodGet, map[string]string{"Range": "bytes=50-"}, `"v1"`, 0},
+		{"last beyond size", http.MethodGet, map[string]string{"Range": "bytes=50-500"}, `"v1"`, 0},
+		{"first at size", http.MethodGet, map[string]string{"Range": "bytes=100-"}, `"v1"`, 416},
+		{"first beyond size", http.MethodGet, map[string]string{"Range": "bytes=200-300"}, `"v1"`, 416},
+		{"one satisfiable of many", http.MethodGet, map[string]string{"Range": "bytes=200-300, 10-20"}, `"v1"`, 0},
+		{"last before first", http.MethodGet, map[string]string{"Range": "bytes=50-10"}, `"v1"`, 416},
+		{"suffix", http.MethodGet, map[string]string{"Range": "bytes=-10"}, `"v1"`, 0},
+		{"suffix longer than size", http.MethodGet, map[string]string{"Range": "bytes=-500"}, `"v1"`, 0},
+		{"empty suffix", http.MethodGet, map[string]string{"Range": "bytes=-0"}, `"v1"`, 416},
+		{"unknown unit", http.MethodGet, map[string]string{"Range": "items=0-5"}, `"v1"`, 0},
+		{"ignored for POST", http.MethodPost, map[string]string{"Range": "bytes=200-"}, `"v1"`, 0},
+
+		{"If-Range matching etag", http.MethodGet, map[string]string{"Range": "bytes=200-", "If-Range": `"v1"`}, `"v1"`, 416},
+		{"If-Range other etag", http.MethodGet, map[string]string{"Range": "bytes=200-", "If-Range": `"v0"`}, `"v1"`, 0},
+		{"If-Range weak etag", http.MethodGet, map[string]string{"Range": "bytes=200-", "If-Range": `W/"v1"`}, `W/"v1"`, 0},
+		{"If-Range matching date", http.MethodGet, map[string]string{
+			"Range": "bytes=200-", "If-Range": modTime.Format(http.TimeFormat),
+		}, `"v1"`, 416},
+		{"If-Range other date", http.MethodGet, map[string]string{
+			"Range": "bytes=200-", "If-Range": modTime.Add(-time.Hour).Format(http.TimeFormat),
+		}, `"v1"`, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/obj", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := status(t, apierr.CheckRange(r, tt.etag, modTime, size)); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRangeEmptyRepresentation(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/obj", nil)
+	r.Header.Set("Range", "bytes=-10")
+	if got := status(t, apierr.CheckRange(r, `"v1"`, modTime, 0)); got != 416 {
+		t.Errorf("status = %d, want 416", got)
+	}
+}
+
+func TestRangeNotSatisfiableContentRange(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/obj", nil)
+	r.Header.Set("Range", "bytes=100-")
+	w := httptest.NewRecorder()
+	apierr.NewRegistry().HandleRequest(apierr.CheckRange(r, `"v1"`, modTime, 100), w, r)
+	if w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("status = %d, want 416", w.Code)
+	}
+	if got := w.Header().Get("Content-Range"); got != "bytes */100" {
+		t.Errorf("Content-Range = %q, want %q", got, "bytes */100")
+	}
+}
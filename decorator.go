@@ -0,0 +1,59 @@
+package apierr
+
+import (
+	"context"
+
+	"schneider.vip/problem"
+)
+
+// Decorator customizes the problem written for err, e.g. adding extensions.
+// ctx is the request context (context.Background for Handle/HandleISE).
+// Decorators receive a copy of the problem, so shared problems are never modified.
+type Decorator func(ctx context.Context, err error, p *problem.Problem)
+
+// AddDecorator registers d in the default registry, see Registry.AddDecorator.
+func AddDecorator(d Decorator) {
+	defaultRegistry.AddDecorator(d)
+}
+
+// AddDecorator registers d; it runs for every problem written by Handle.
+func (r *Registry) AddDecorator(d Decorator) {
+	r.decorators = append(r.decorators, d)
+}
+
+type decoratorsKey struct{}
+
+// WithDecorator returns a copy of ctx carrying d: it runs after the registered decorators, only
+// for the errors handled with HandleRequest/HandleRequestISE for a request with that context.
+//
+// Example:
+//
+//	func (h *OrderHandler) Get(w http.ResponseWriter, r *http.Request) {
+//		ctx := apierr.WithDecorator(r.Context(), func(_ context.Context, _ error, p *problem.Problem) {
+//			w.Header().Set("X-Order-Id", r.PathValue("id"))
+//		})
+//		r = r.WithContext(ctx)
+//		// ...
+//	}
+func WithDecorator(ctx context.Context, d Decorator) context.Context {
+	parent, _ := ctx.Value(decoratorsKey{}).([]Decorator)
+	ds := make([]Decorator, len(parent), len(parent)+1)
+	copy(ds, parent)
+	return context.WithValue(ctx, decoratorsKey{}, append(ds, d))
+}
+
+// decorate runs the registered decorators and the ones carried by ctx on a copy of p.
+func (r *Registry) decorate(ctx context.Context, err error, p *problem.Problem) *problem.Problem {
+	scoped, _ := ctx.Value(decoratorsKey{}).([]Decorator)
+	if len(r.decorators) == 0 && len(scoped) == 0 {
+		return p
+	}
+	p = cloneProblem(p)
+	for _, d := range r.decorators {
+		d(ctx, err, p)
+	}
+	for _, d := range scoped {
+		d(ctx, err, p)
+	}
+	return p
+}
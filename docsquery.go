@@ -0,0 +1,58 @@
+package apierr
+
+import (
+	"errors"
+	"strings"
+
+	"schneider.vip/problem"
+)
+
+// DocsQueryExtension is the problem extension carrying the documentation search terms
+// of the code of the error, see Registry.DocsQuery.
+const DocsQueryExtension = "docs_query"
+
+// WithDocsQuery sets the documentation search terms of c, replacing the ones computed
+// by Register from its ID and title.
+//
+// Example:
+//
+//	var ErrQuota = apierr.Register("QUOTA_EXCEEDED", apierr.TooManyRequests, "quota exceeded").
+//		WithDocsQuery("quota", "limits", "billing plan")
+func (c *Code) WithDocsQuery(terms ...string) *Code {
+	c.DocsQuery = strings.Join(terms, " ")
+	return c
+}
+
+// docsQuery returns the search terms of a code: the lowercase words of its ID and
+// title, without duplicates.
+func docsQuery(id, title string) string {
+	var terms []string
+	seen := map[string]bool{}
+	split := func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}
+	for _, w := range strings.FieldsFunc(strings.ToLower(id+" "+title), split) {
+		if !seen[w] {
+			seen[w] = true
+			terms = append(terms, w)
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// applyDocsQuery sets the DocsQueryExtension of p, on a copy, when enabled and err
+// has a registered code.
+func (r *Registry) applyDocsQuery(err error, p *problem.Problem) *problem.Problem {
+	if !r.DocsQuery {
+		return p
+	}
+	var e *APIErr
+	if !errors.As(err, &e) {
+		return p
+	}
+	c, ok := r.codeOf(e)
+	if !ok || c.DocsQuery == "" {
+		return p
+	}
+	return cloneProblem(p).Append(problem.Custom(DocsQueryExtension, c.DocsQuery))
+}
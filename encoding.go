@@ -0,0 +1,162 @@
+package apierr
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"schneider.vip/problem"
+)
+
+// Encoder serializes problems in a media type.
+type Encoder interface {
+	// ContentType is the media type produced by the encoder.
+	ContentType() string
+	Encode(w io.Writer, p *problem.Problem) error
+}
+
+// JSONEncoder encodes problems as application/problem+json (RFC 7807).
+type JSONEncoder struct{}
+
+func (JSONEncoder) ContentType() string { return problem.ContentTypeJSON }
+
+func (JSONEncoder) Encode(w io.Writer, p *problem.Problem) error {
+	_, err := w.Write(p.JSON())
+	return err
+}
+
+// XMLEncoder encodes problems as application/problem+xml (RFC 7807).
+type XMLEncoder struct{}
+
+func (XMLEncoder) ContentType() string { return problem.ContentTypeXML }
+
+func (XMLEncoder) Encode(w io.Writer, p *problem.Problem) error {
+	_, err := w.Write(p.XML())
+	return err
+}
+
+// TextEncoder encodes problems as text/plain: the status and title, followed by the detail.
+type TextEncoder struct{}
+
+func (TextEncoder) ContentType() string { return "text/plain; charset=utf-8" }
+
+func (TextEncoder) Encode(w io.Writer, p *problem.Problem) error {
+	data := problemData(p)
+	status, _ := data["status"].(float64)
+	text := fmt.Sprintf("%d %v\n", int(status), data["title"])
+	if detail, ok := data["detail"].(string); ok {
+		text += "\n" + detail + "\n"
+	}
+	_, err := io.WriteString(w, text)
+	return err
+}
+
+// RegisterEncoder registers e in the default registry, see Registry.RegisterEncoder.
+func RegisterEncoder(e Encoder) {
+	defaultRegistry.RegisterEncoder(e)
+}
+
+// RegisterEncoder registers e, replacing the encoder with the same content type if any.
+// The request-aware functions (HandleRequest...) pick the encoder with the Accept header
+// of the request, while Handle and HandleISE always use the first one.
+// A new Registry has JSONEncoder, XMLEncoder and TextEncoder.
+func (r *Registry) RegisterEncoder(e Encoder) {
+	if r.encoders == nil {
+		r.encoders = slices.Clone(defaultEncoders)
+	}
+	for i, enc := range r.encoders {
+		if enc.ContentType() == e.ContentType() {
+			r.encoders[i] = e
+			return
+		}
+	}
+	r.encoders = append(r.encoders, e)
+}
+
+// encoder returns the encoder negotiated with the Accept header of req, or
+// the first registered one when req is nil or nothing is acceptable.
+// A LocalizedEncoder is bound to the languages of req.
+func (r *Registry) encoder(req *http.Request) Encoder {
+	enc := r.negotiate(req)
+	if le, ok := enc.(LocalizedEncoder); ok && req != nil {
+		return localizedEncoder{LocalizedEncoder: le, langs: r.languages(req)}
+	}
+	return enc
+}
+
+func (r *Registry) negotiate(req *http.Request) Encoder {
+	if req != nil {
+		for _, ar := range parseAccept(req.Header.Get("Accept")) {
+			for _, enc := range r.encoderList() {
+				if mediaMatch(ar, enc.ContentType()) {
+					return enc
+				}
+			}
+		}
+	}
+	return r.encoderList()[0]
+}
+
+// encoderList returns the registered encoders, the ones of NewRegistry for a zero Registry.
+func (r *Registry) encoderList() []Encoder {
+	if r.encoders == nil {
+		return defaultEncoders
+	}
+	return r.encoders
+}
+
+// parseAccept returns the acceptable media ranges of an Accept header, by decreasing quality.
+func parseAccept(accept string) []string {
+	type mediaRange struct {
+		value string
+		q     float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{value: mt, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	values := make([]string, len(ranges))
+	for i, mr := range ranges {
+		values[i] = mr.value
+	}
+	return values
+}
+
+// mediaMatch reports whether the media range accept matches contentType. Besides
+// wildcards, a structured syntax suffix matches its base type, e.g. application/json
+// matches application/problem+json.
+func mediaMatch(accept, contentType string) bool {
+	ct, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if accept == "*/*" || accept == ct {
+		return true
+	}
+	typ, sub, _ := strings.Cut(ct, "/")
+	if accept == typ+"/*" {
+		return true
+	}
+	if _, suffix, ok := strings.Cut(sub, "+"); ok {
+		return accept == typ+"/"+suffix
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+// Package enterr maps the errors of the ent ORM (entgo.io/ent) to problems: missing
+// entities to 404, constraint violations and non-singular results to 409.
+//
+// ent generates its error types in the ent package of each project, hence they are
+// recognized by type name and message and this package does not depend on ent.
+//
+// Example:
+//
+//	if err := apierr.RegisterHandlers(enterr.Handlers()); err != nil {
+//		log.Fatal(err)
+//	}
+package enterr
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/debyten/apierr"
+	"schneider.vip/problem"
+)
+
+// EntityExtension is the problem extension carrying the entity or edge label of the error.
+const EntityExtension = "entity"
+
+// messagePrefix is the prefix of the messages of the ent errors.
+const messagePrefix = "ent: "
+
+// Handlers returns the handlers of the package, to be registered with apierr.RegisterHandlers.
+func Handlers() []apierr.NamedHandler {
+	return []apierr.NamedHandler{
+		{Name: "enterr.not-found", Handler: NotFound},
+		{Name: "enterr.constraint", Handler: Constraint},
+		{Name: "enterr.not-singular", Handler: NotSingular},
+	}
+}
+
+// NotFound maps the ent.NotFoundError (ent.IsNotFound) to 404.
+func NotFound(err error) *problem.Problem {
+	label, ok := find(err, "NotFoundError", " not found")
+	if !ok {
+		return nil
+	}
+	return withEntity(apierr.NotFound.Problem(http.StatusText(http.StatusNotFound)), label)
+}
+
+// Constraint maps the ent.ConstraintError (ent.IsConstraintError) to 409.
+// The message of the database is not exposed to the clients.
+func Constraint(err error) *problem.Problem {
+	if _, ok := find(err, "ConstraintError", ""); !ok {
+		return nil
+	}
+	return apierr.Conflict.Problem("constraint violated")
+}
+
+// NotSingular maps the ent.NotSingularError (ent.IsNotSingular) to 409: a query
+// expected to return a single entity returned more.
+func NotSingular(err error) *problem.Problem {
+	label, ok := find(err, "NotSingularError", " not singular")
+	if !ok {
+		return nil
+	}
+	return withEntity(apierr.Conflict.Problem("multiple entities found"), label)
+}
+
+func withEntity(p *problem.Problem, label string) *problem.Problem {
+	if label != "" {
+		p.Append(problem.Custom(EntityExtension, label))
+	}
+	return p
+}
+
+// find returns the label of the first ent error of type typeName in the chain of err:
+// the text of its message between the "ent: " prefix and suffix.
+func find(err error, typeName, suffix string) (string, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		t := reflect.TypeOf(err)
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Name() != typeName {
+			continue
+		}
+		msg, ok := strings.CutPrefix(err.Error(), messagePrefix)
+		if !ok {
+			continue
+		}
+		if suffix == "" {
+			return "", true
+		}
+		label, _ := strings.CutSuffix(msg, suffix)
+		return label, true
+	}
+	return "", false
+}
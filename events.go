@@ -0,0 +1,86 @@
+package apierr
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Event is emitted to the subscribers for every error response written by the registry.
+type Event struct {
+	Outcome
+	Err  error
+	Time time.Time
+	// Method and Path of the request, empty when the error is not handled
+	// through a request-aware function.
+	Method string
+	Path   string
+}
+
+// eventBus delivers the events to the subscribers.
+type eventBus struct {
+	mu   sync.RWMutex
+	subs map[chan Event]struct{}
+}
+
+// Subscribe subscribes to the events of the default registry, see Registry.Subscribe.
+func Subscribe(buffer int) (<-chan Event, func()) {
+	return defaultRegistry.Subscribe(buffer)
+}
+
+// Subscribe returns a channel receiving the events of the error responses written
+// by r, and the function to cancel the subscription, which closes the channel.
+// Events are never waited for: when the buffer of a subscriber is full the event is
+// dropped and counted in the "events_dropped" expvar counter.
+//
+// Example:
+//
+//	events, cancel := apierr.Subscribe(1024)
+//	defer cancel()
+//	for e := range events {
+//		analytics.Track(e.Code, e.Status, e.Path)
+//	}
+func (r *Registry) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	if r.events == nil {
+		// zero Registry: subscribing is part of its configuration
+		r.events = &eventBus{}
+	}
+	r.events.mu.Lock()
+	if r.events.subs == nil {
+		r.events.subs = map[chan Event]struct{}{}
+	}
+	r.events.subs[ch] = struct{}{}
+	r.events.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			r.events.mu.Lock()
+			delete(r.events.subs, ch)
+			r.events.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (r *Registry) emit(req *http.Request, err error, o Outcome) {
+	if r.events == nil {
+		return
+	}
+	r.events.mu.RLock()
+	defer r.events.mu.RUnlock()
+	if len(r.events.subs) == 0 {
+		return
+	}
+	e := Event{Outcome: o, Err: err, Time: time.Now()}
+	if req != nil {
+		e.Method, e.Path = req.Method, req.URL.Path
+	}
+	for ch := range r.events.subs {
+		select {
+		case ch <- e:
+		default:
+			errorCounters.Add("events_dropped", 1)
+		}
+	}
+}
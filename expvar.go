@@ -0,0 +1,34 @@
+package apierr
+
+import (
+	"expvar"
+	"strconv"
+	"sync"
+)
+
+// errorCounters counts the responses written by Handle/HandleISE:
+//
+//   - "handled": all the errors written;
+//   - "4xx", "5xx"...: the errors written by status class;
+//   - "fallback": the unknown errors replied with Internal Server Error;
+//   - "hook_timeouts": the hooks exceeding Registry.HookTimeout;
+//   - "events_dropped": the events dropped because of a full subscriber, see Subscribe.
+var errorCounters = new(expvar.Map).Init()
+
+var publishOnce sync.Once
+
+// PublishExpvar publishes the error counters as the "apierr" expvar, served
+// with the other variables under /debug/vars. It is safe to call it more than once.
+func PublishExpvar() {
+	publishOnce.Do(func() {
+		expvar.Publish("apierr", errorCounters)
+	})
+}
+
+func countOutcome(o Outcome) {
+	errorCounters.Add("handled", 1)
+	errorCounters.Add(strconv.Itoa(o.Status/100)+"xx", 1)
+	if !o.Handled {
+		errorCounters.Add("fallback", 1)
+	}
+}
@@ -0,0 +1,157 @@
+package apierr
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"schneider.vip/problem"
+)
+
+// ExtensionMismatchError is returned by AttachExtension when a value does not
+// match the type of its extension in the catalog of RegisterType.
+type ExtensionMismatchError struct {
+	Key      string
+	Expected reflect.Type
+	Actual   reflect.Type
+}
+
+func (e *ExtensionMismatchError) Error() string {
+	return fmt.Sprintf("extension %q: expected %v, got %v", e.Key, e.Expected, e.Actual)
+}
+
+// AttachExtension sets the extension key of p to value, checked against the catalog of
+// the default registry, see Registry.AttachExtension.
+//
+// Example:
+//
+//	apierr.RegisterType("https://api.example.com/errors/quota-exceeded", quotaExceeded{})
+//
+//	err := apierr.AttachExtension(p, "limit", 100)
+func AttachExtension(p *problem.Problem, key string, value any) error {
+	return defaultRegistry.AttachExtension(p, key, value)
+}
+
+// AttachExtension sets the extension key of p to value. When StrictExtensions is enabled
+// and value is not assignable to the type of the extension in the catalog, p is left
+// unchanged and an *ExtensionMismatchError is returned.
+//
+// The type of an extension is the one of the field named key in the extensions
+// registered with RegisterType for the type of p or, when that type does not declare
+// it, for the other problem types. Extensions not in the catalog are attached without checks.
+func (r *Registry) AttachExtension(p *problem.Problem, key string, value any) error {
+	typeURI, _ := problemData(p)["type"].(string)
+	if err := r.checkExtension(typeURI, key, value); err != nil {
+		return err
+	}
+	p.Append(problem.Custom(key, value))
+	return nil
+}
+
+// checkExtension returns an *ExtensionMismatchError when StrictExtensions is enabled
+// and value is not assignable to the type of key in the catalog of r.
+func (r *Registry) checkExtension(typeURI, key string, value any) error {
+	if !r.StrictExtensions {
+		return nil
+	}
+	expected, ok := r.extensionType(typeURI, key)
+	if ok && !assignable(value, expected) {
+		return &ExtensionMismatchError{Key: key, Expected: expected, Actual: reflect.TypeOf(value)}
+	}
+	return nil
+}
+
+// extensionType returns the type of the extension key declared for typeURI with
+// RegisterType, or else by the most recent problem type declaring it.
+func (r *Registry) extensionType(typeURI, key string) (reflect.Type, bool) {
+	if pt, ok := r.lookupType(typeURI); ok {
+		if t, ok := pt.extension(key); ok {
+			return t, true
+		}
+	}
+	for i := len(r.problemTypes) - 1; i >= 0; i-- {
+		if t, ok := r.problemTypes[i].extension(key); ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// extension returns the type of the field of the extensions of pt named key.
+func (pt problemType) extension(key string) (reflect.Type, bool) {
+	if pt.extensions == nil || pt.extensions.Kind() != reflect.Struct {
+		return nil, false
+	}
+	for i := 0; i < pt.extensions.NumField(); i++ {
+		f := pt.extensions.Field(i)
+		if name, ok := jsonName(f); ok && name == key {
+			return f.Type, true
+		}
+	}
+	return nil, false
+}
+
+// jsonName returns the member name of the exported field f, false when it is not encoded.
+func jsonName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, true
+}
+
+// validateExtensions returns the extensions declared with different types by the
+// registered problem types.
+func (r *Registry) validateExtensions() []error {
+	declared := map[string]map[reflect.Type][]string{}
+	for _, pt := range r.problemTypes {
+		if pt.extensions == nil || pt.extensions.Kind() != reflect.Struct {
+			continue
+		}
+		for i := 0; i < pt.extensions.NumField(); i++ {
+			f := pt.extensions.Field(i)
+			name, ok := jsonName(f)
+			if !ok {
+				continue
+			}
+			if declared[name] == nil {
+				declared[name] = map[reflect.Type][]string{}
+			}
+			declared[name][f.Type] = append(declared[name][f.Type], pt.uri)
+		}
+	}
+	var errs []error
+	for _, name := range sortedKeys(declared) {
+		if len(declared[name]) < 2 {
+			continue
+		}
+		var shapes []string
+		for t, uris := range declared[name] {
+			shapes = append(shapes, fmt.Sprintf("%v (%s)", t, strings.Join(uris, ", ")))
+		}
+		sort.Strings(shapes)
+		errs = append(errs, fmt.Errorf("extension %q declared with different types: %s", name, strings.Join(shapes, "; ")))
+	}
+	return errs
+}
+
+func assignable(value any, expected reflect.Type) bool {
+	if expected == nil {
+		return value == nil
+	}
+	if value == nil {
+		switch expected.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+			return true
+		}
+		return false
+	}
+	return reflect.TypeOf(value).AssignableTo(expected)
+}
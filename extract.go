@@ -0,0 +1,99 @@
+package apierr
+
+import (
+	"net/http"
+
+	"schneider.vip/problem"
+)
+
+// ProblemPolicy selects the problem replied when an error tree (see errors.Join)
+// contains more than one.
+type ProblemPolicy int
+
+const (
+	// FirstProblem selects the first problem found walking the tree depth-first.
+	FirstProblem ProblemPolicy = iota
+	// HighestStatus selects the problem with the highest status, the first one on ties.
+	HighestStatus
+)
+
+// maxTreeDepth bounds the walk of error trees, protecting from cyclic chains.
+const maxTreeDepth = 100
+
+type foundProblem struct {
+	p      *problem.Problem
+	header http.Header
+}
+
+// findProblem walks the tree of err, following both Unwrap() []error and the
+// registry unwrappers, and returns the problem selected by r.Policy.
+func (r *Registry) findProblem(err error) (*problem.Problem, http.Header) {
+	var found []foundProblem
+	r.walkTree(err, 0, func(f foundProblem) bool {
+		found = append(found, f)
+		return r.Policy == HighestStatus
+	})
+	if len(found) == 0 {
+		return nil, nil
+	}
+	best, bestStatus := found[0], problemStatus(found[0].p)
+	for _, f := range found[1:] {
+		if s := problemStatus(f.p); s > bestStatus {
+			best, bestStatus = f, s
+		}
+	}
+	return best.p, best.header
+}
+
+// walkTree visits the problems of the tree of err depth-first, until visit returns false.
+func (r *Registry) walkTree(err error, depth int, visit func(foundProblem) bool) bool {
+	for ; err != nil && depth < maxTreeDepth; depth++ {
+		if f, ok := asProblem(err); ok {
+			return visit(f)
+		}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range joined.Unwrap() {
+				if !r.walkTree(e, depth+1, visit) {
+					return false
+				}
+			}
+			return true
+		}
+		err = r.unwrap(err)
+	}
+	return true
+}
+
+// problemer is implemented by the errors convertible to a problem (APIErr, ValidationError...).
+// They can implement Headers() http.Header as well, to write headers along with the problem.
+type problemer interface {
+	Problem() *problem.Problem
+}
+
+// asProblem converts err to a problem when it is a problem.Problem or a problemer,
+// directly or through an As method.
+func asProblem(err error) (foundProblem, bool) {
+	switch e := err.(type) {
+	case *problem.Problem:
+		return foundProblem{p: e}, true
+	case problem.Problem:
+		return foundProblem{p: &e}, true
+	case problemer:
+		f := foundProblem{p: e.Problem()}
+		if h, ok := e.(interface{ Headers() http.Header }); ok {
+			f.header = h.Headers()
+		}
+		return f, true
+	}
+	if as, ok := err.(interface{ As(any) bool }); ok {
+		var apiErr *APIErr
+		if as.As(&apiErr) {
+			return foundProblem{p: apiErr.Problem(), header: apiErr.Headers()}, true
+		}
+		var p *problem.Problem
+		if as.As(&p) {
+			return foundProblem{p: p}, true
+		}
+	}
+	return foundProblem{}, false
+}
@@ -0,0 +1,58 @@
+package apierr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/debyten/apierr"
+)
+
+func TestExtractJoinedProblems(t *testing.T) {
+	conflict := apierr.Conflict.Problem("conflict")
+	unavailable := apierr.ServiceUnavailable.Problem("unavailable")
+	unavailableToo := apierr.ServiceUnavailable.Problem("unavailable too")
+	plain := errors.New("plain")
+	tests := []struct {
+		name       string
+		err        error
+		policy     apierr.ProblemPolicy
+		wantStatus int
+		wantTitle  string
+	}{
+		{"joined with a plain error", errors.Join(plain, conflict), apierr.FirstProblem, 409, "conflict"},
+		{"first problem", errors.Join(conflict, unavailable), apierr.FirstProblem, 409, "conflict"},
+		{"highest status", errors.Join(conflict, unavailable), apierr.HighestStatus, 503, "unavailable"},
+		{"highest status tie", errors.Join(unavailable, conflict, unavailableToo), apierr.HighestStatus, 503, "unavailable"},
+		{"nested join", fmt.Errorf("save: %w", errors.Join(plain, errors.Join(plain, unavailable))), apierr.FirstProblem, 503, "unavailable"},
+		{"depth first", errors.Join(errors.Join(plain, conflict), unavailable), apierr.FirstProblem, 409, "conflict"},
+		{"multiple %w", fmt.Errorf("%w and %w", plain, conflict), apierr.FirstProblem, 409, "conflict"},
+		{"APIErr in a join", errors.Join(plain, apierr.NotFound.Err(errors.New("no user"))), apierr.FirstProblem, 404, "Not Found"},
+		{"no problem", errors.Join(plain, errors.New("other")), apierr.HighestStatus, 500, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := apierr.NewRegistry()
+			r.Policy = tt.policy
+			w := httptest.NewRecorder()
+			r.HandleISE(tt.err, w)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantTitle == "" {
+				return
+			}
+			var body struct {
+				Title string `json:"title"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", body.Title, tt.wantTitle)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package apierr
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// ContextExtractor extracts a value (user id, tenant, session...) from a request context.
+type ContextExtractor func(ctx context.Context) (string, bool)
+
+// RequestExtractor extracts a value (client SDK version, platform...) from the headers of a request.
+type RequestExtractor func(r *http.Request) (string, bool)
+
+// namedExtractor has either fn or req set.
+type namedExtractor struct {
+	key string
+	fn  ContextExtractor
+	req RequestExtractor
+}
+
+// RegisterContextExtractor registers fn in the default registry, see Registry.RegisterContextExtractor.
+func RegisterContextExtractor(key string, fn ContextExtractor) {
+	defaultRegistry.RegisterContextExtractor(key, fn)
+}
+
+// RegisterContextExtractor registers fn as the extractor of the value key. The extracted
+// values are available to decorators through ContextValues and are sent to notifiers.
+//
+// Example:
+//
+//	apierr.RegisterContextExtractor("tenant", func(ctx context.Context) (string, bool) {
+//		t, ok := tenant.FromContext(ctx)
+//		return t.ID, ok
+//	})
+func (r *Registry) RegisterContextExtractor(key string, fn ContextExtractor) {
+	r.registerExtractor(namedExtractor{key: key, fn: fn})
+}
+
+// RegisterRequestExtractor registers fn in the default registry, see Registry.RegisterRequestExtractor.
+func RegisterRequestExtractor(key string, fn RequestExtractor) {
+	defaultRegistry.RegisterRequestExtractor(key, fn)
+}
+
+// RegisterRequestExtractor registers fn as the extractor of the value key from the
+// request. Like the values of the context extractors, the extracted values are sent
+// to notifiers, and they are in the Outcome of the request (see TrackOutcome and
+// Subscribe), so that analytics can relate the error codes to the client versions.
+// They are extracted only by the request-aware functions (HandleRequest...).
+//
+// Example:
+//
+//	apierr.RegisterRequestExtractor("sdk", apierr.SDKVersion("X-SDK-Version", "acme-sdk"))
+func (r *Registry) RegisterRequestExtractor(key string, fn RequestExtractor) {
+	r.registerExtractor(namedExtractor{key: key, req: fn})
+}
+
+func (r *Registry) registerExtractor(ne namedExtractor) {
+	for i, e := range r.extractors {
+		if e.key == ne.key {
+			r.extractors[i] = ne
+			return
+		}
+	}
+	r.extractors = append(r.extractors, ne)
+}
+
+// SDKVersion returns a RequestExtractor of the client SDK version: the value of header,
+// when present, otherwise the product/version token of product in the User-Agent
+// (e.g. "acme-sdk/1.4.2" in "acme-sdk/1.4.2 go1.22 linux").
+func SDKVersion(header, product string) RequestExtractor {
+	return func(r *http.Request) (string, bool) {
+		if v := r.Header.Get(header); v != "" {
+			return v, true
+		}
+		for _, token := range strings.Fields(r.UserAgent()) {
+			if name, _, ok := strings.Cut(token, "/"); ok && strings.EqualFold(name, product) {
+				return token, true
+			}
+		}
+		return "", false
+	}
+}
+
+type valuesKey struct{}
+
+// ContextValues returns the values of ctx extracted by the registered extractors.
+// While an error is handled the values are extracted once, by the registry handling
+// the error, and shared by all the hooks; otherwise the default registry extractors are used.
+func ContextValues(ctx context.Context) map[string]string {
+	return defaultRegistry.contextValues(ctx, nil)
+}
+
+func (r *Registry) contextValues(ctx context.Context, req *http.Request) map[string]string {
+	if values, ok := ctx.Value(valuesKey{}).(map[string]string); ok {
+		return values
+	}
+	return r.extractValues(ctx, req)
+}
+
+// extractValues runs the extractors; the request extractors are skipped when req is nil.
+func (r *Registry) extractValues(ctx context.Context, req *http.Request) map[string]string {
+	if len(r.extractors) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(r.extractors))
+	for _, e := range r.extractors {
+		var v string
+		var ok bool
+		switch {
+		case e.fn != nil:
+			v, ok = e.fn(ctx)
+		case req != nil:
+			v, ok = e.req(req)
+		}
+		if ok {
+			values[e.key] = v
+		}
+	}
+	return values
+}
+
+// withValues returns ctx carrying the extracted values.
+func (r *Registry) withValues(ctx context.Context, req *http.Request) context.Context {
+	if len(r.extractors) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, valuesKey{}, r.extractValues(ctx, req))
+}
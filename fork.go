@@ -0,0 +1,90 @@
+package apierr
+
+import (
+	"errors"
+	"maps"
+	"slices"
+
+	"schneider.vip/problem"
+)
+
+// Clone returns a copy of e whose headers, extras and problem options can be changed
+// without affecting e.
+func (e *APIErr) Clone() *APIErr {
+	c := *e
+	c.headers = e.headers.Clone()
+	c.extras = maps.Clone(e.extras)
+	c.opts = slices.Clip(e.opts)
+	c.extErrs = slices.Clip(e.extErrs)
+	return &c
+}
+
+// CloneProblem returns a copy of p that can be changed without affecting p.
+func CloneProblem(p *problem.Problem) *problem.Problem {
+	return cloneProblem(p)
+}
+
+// Fork returns a private copy of err for one of the requests sharing it, e.g. the
+// waiters of a request-coalescing layer (singleflight) fanning out one upstream error.
+// Each request can then customize its error (APIErr.WithHeader...) while it is handled
+// concurrently with the others. errors.Is and errors.As still see the tree of err,
+// except that the first APIErr or problem.Problem found is a copy.
+//
+// Example:
+//
+//	v, err, _ := group.Do(key, fetch)
+//	if err != nil {
+//		return apierr.Fork(err)
+//	}
+func Fork(err error) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *APIErr:
+		return e.Clone()
+	case *problem.Problem:
+		return cloneProblem(e)
+	}
+	f := &forkedErr{err: err}
+	if errors.As(err, &f.apiErr) {
+		f.apiErr = f.apiErr.Clone()
+	}
+	if errors.As(err, &f.problem) {
+		f.problem = cloneProblem(f.problem)
+	}
+	return f
+}
+
+// forkedErr wraps a shared error, replacing its APIErr and problem.Problem with copies.
+type forkedErr struct {
+	err     error
+	apiErr  *APIErr
+	problem *problem.Problem
+}
+
+func (f *forkedErr) Error() string {
+	return f.err.Error()
+}
+
+func (f *forkedErr) Unwrap() error {
+	return f.err
+}
+
+// As sets target to the copy of the APIErr or problem.Problem of the shared error.
+func (f *forkedErr) As(target any) bool {
+	switch t := target.(type) {
+	case **APIErr:
+		if f.apiErr == nil {
+			return false
+		}
+		*t = f.apiErr
+		return true
+	case **problem.Problem:
+		if f.problem == nil {
+			return false
+		}
+		*t = f.problem
+		return true
+	}
+	return false
+}
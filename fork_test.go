@@ -0,0 +1,99 @@
+package apierr
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"schneider.vip/problem"
+)
+
+func TestCloneIndependentAppends(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+	e := BadRequest.Err(errors.New("invalid order"))
+	// spare capacity, as left by a previous append
+	e.extErrs = append(make([]error, 0, 4), errors.New("first"))
+	c := e.Clone()
+
+	e.extErrs = append(e.extErrs, errA)
+	c.extErrs = append(c.extErrs, errB)
+	if e.extErrs[1] != errA || c.extErrs[1] != errB {
+		t.Errorf("extension errors shared: original %v, clone %v", e.extErrs, c.extErrs)
+	}
+
+	e.WithHeader("X-Original", "1")
+	c.WithHeader("X-Clone", "1")
+	if e.Headers().Get("X-Clone") != "" || c.Headers().Get("X-Original") != "" {
+		t.Errorf("headers shared: original %v, clone %v", e.Headers(), c.Headers())
+	}
+}
+
+func TestFork(t *testing.T) {
+	shared := Conflict.Err(errors.New("version conflict")).WithHeader("X-Shared", "1")
+	sharedProblem := Conflict.Problem("version conflict")
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"APIErr", shared},
+		{"wrapped APIErr", fmt.Errorf("save order: %w", shared)},
+		{"problem", sharedProblem},
+		{"wrapped problem", fmt.Errorf("save order: %w", sharedProblem)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forked := Fork(tt.err)
+			if forked.Error() != tt.err.Error() {
+				t.Errorf("Error() = %q, want %q", forked.Error(), tt.err.Error())
+			}
+			var ae *APIErr
+			if errors.As(tt.err, &ae) {
+				var fae *APIErr
+				if !errors.As(forked, &fae) || fae == ae {
+					t.Fatalf("fork APIErr = %p, want a copy of %p", fae, ae)
+				}
+				fae.WithHeader("X-Request", "2")
+				if ae.Headers().Get("X-Request") != "" || fae.Headers().Get("X-Shared") != "1" {
+					t.Errorf("headers: shared %v, fork %v", ae.Headers(), fae.Headers())
+				}
+			}
+			var p *problem.Problem
+			if errors.As(tt.err, &p) {
+				var fp *problem.Problem
+				if !errors.As(forked, &fp) || fp == p {
+					t.Fatalf("fork problem = %p, want a copy of %p", fp, p)
+				}
+				fp.Append(problem.Custom("request", 2))
+				if _, ok := problemData(p)["request"]; ok {
+					t.Errorf("shared problem changed: %s", p.JSON())
+				}
+			}
+		})
+	}
+	if Fork(nil) != nil {
+		t.Error("Fork(nil) != nil")
+	}
+}
+
+func TestForkConcurrent(t *testing.T) {
+	shared := fmt.Errorf("fetch: %w", ServiceUnavailable.Err(errors.New("upstream down")))
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var ae *APIErr
+			if errors.As(Fork(shared), &ae) {
+				ae.WithHeader("X-Request", strconv.Itoa(i)).WithExtension("attempt", i)
+			}
+		}()
+	}
+	wg.Wait()
+	var ae *APIErr
+	errors.As(shared, &ae)
+	if ae.Headers().Get("X-Request") != "" || len(ae.opts) != 0 {
+		t.Errorf("shared APIErr changed: headers %v, %d options", ae.Headers(), len(ae.opts))
+	}
+}
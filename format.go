@@ -0,0 +1,80 @@
+package apierr
+
+import (
+	"net/http"
+
+	"schneider.vip/problem"
+)
+
+// FormatVersionHeader is the request header selecting the version of the error
+// format (see Registry.RegisterFormatVersion). It is echoed in the response.
+const FormatVersionHeader = "X-Error-Format"
+
+// RegisterFormatVersion registers version in the default registry, see Registry.RegisterFormatVersion.
+func RegisterFormatVersion(version string, renames map[string]string) {
+	defaultRegistry.RegisterFormatVersion(version, renames)
+}
+
+// RegisterFormatVersion registers a version of the error format, so that extension names
+// can evolve without breaking the clients: renames maps the current name of a member to
+// its name in version. Clients select the version with the FormatVersionHeader; when the
+// header is missing or unknown, Registry.FormatVersion is used.
+//
+// Example:
+//
+//	apierr.RegisterFormatVersion("v1", map[string]string{apierr.AttemptsExtension: "remaining"})
+//	apierr.RegisterFormatVersion("v2", nil)
+//	apierr.DefaultRegistry().FormatVersion = "v1"
+func (r *Registry) RegisterFormatVersion(version string, renames map[string]string) {
+	if r.formats == nil {
+		r.formats = map[string]map[string]string{}
+	}
+	r.formats[version] = renames
+}
+
+// formatVersion returns the version requested by req, if registered, or the default one.
+func (r *Registry) formatVersion(req *http.Request) string {
+	if req != nil {
+		if v := req.Header.Get(FormatVersionHeader); v != "" {
+			if _, ok := r.formats[v]; ok {
+				return v
+			}
+		}
+	}
+	return r.FormatVersion
+}
+
+// applyFormatVersion renames the members of p for the version requested by req and
+// advertises the version in the response.
+func (r *Registry) applyFormatVersion(w http.ResponseWriter, req *http.Request, p *problem.Problem) *problem.Problem {
+	if len(r.formats) == 0 {
+		return p
+	}
+	w.Header().Add("Vary", FormatVersionHeader)
+	version := r.formatVersion(req)
+	if version == "" {
+		return p
+	}
+	w.Header().Set(FormatVersionHeader, version)
+	renames := r.formats[version]
+	if len(renames) == 0 {
+		return p
+	}
+	data := problemMembers(p)
+	renamed := false
+	for from, to := range renames {
+		if v, ok := data[from]; ok {
+			delete(data, from)
+			data[to] = v
+			renamed = true
+		}
+	}
+	if !renamed {
+		return p
+	}
+	c := problemFromData(data)
+	if reason := p.Unwrap(); reason != nil {
+		c.Append(problem.WrapSilent(reason))
+	}
+	return c
+}
@@ -0,0 +1,131 @@
+// Package gormerr maps the errors of GORM (gorm.io/gorm) and of its SQL drivers to
+// problems: missing records to 404, duplicate keys to 409 and foreign key violations
+// to 422. It replaces apierr.DefaultDBNotFoundHandler.
+//
+// The errors are recognized by their messages, hence this package does not depend on
+// GORM nor on the drivers. The driver messages are not exposed to the clients.
+//
+// Example:
+//
+//	if err := apierr.RegisterHandlers(gormerr.Handlers()); err != nil {
+//		log.Fatal(err)
+//	}
+package gormerr
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/debyten/apierr"
+	"schneider.vip/problem"
+)
+
+// Messages of the errors of gorm.
+const (
+	recordNotFoundMessage = "record not found"
+	duplicatedKeyMessage  = "duplicated key not allowed"
+	foreignKeyMessage     = "violates foreign key constraint"
+)
+
+// duplicateKeyMessages identify the unique violations of the drivers
+// (PostgreSQL 23505, MySQL 1062, SQLite, SQL Server 2601/2627).
+var duplicateKeyMessages = []string{
+	duplicatedKeyMessage,
+	"duplicate key value violates unique constraint",
+	"Duplicate entry",
+	"UNIQUE constraint failed",
+	"Cannot insert duplicate key",
+}
+
+// foreignKeyMessages identify the foreign key violations of the drivers
+// (PostgreSQL 23503, MySQL 1451/1452, SQLite, SQL Server 547).
+var foreignKeyMessages = []string{
+	foreignKeyMessage,
+	"a foreign key constraint fails",
+	"FOREIGN KEY constraint failed",
+	"conflicted with the FOREIGN KEY constraint",
+	"conflicted with the REFERENCE constraint",
+}
+
+// Handlers returns the handlers of the package, to be registered with apierr.RegisterHandlers.
+// Each one can be registered with apierr.AddHandler as well.
+func Handlers() []apierr.NamedHandler {
+	return []apierr.NamedHandler{
+		{Name: "gormerr.not-found", Handler: NotFound},
+		{Name: "gormerr.duplicate-key", Handler: DuplicateKey},
+		{Name: "gormerr.foreign-key", Handler: ForeignKey},
+	}
+}
+
+// NotFound maps gorm.ErrRecordNotFound to 404.
+func NotFound(err error) *problem.Problem {
+	if !IsNotFound(err) {
+		return nil
+	}
+	return apierr.NotFound.Problem(http.StatusText(http.StatusNotFound))
+}
+
+// DuplicateKey maps the unique constraint violations to 409.
+func DuplicateKey(err error) *problem.Problem {
+	if !IsDuplicateKey(err) {
+		return nil
+	}
+	return apierr.Conflict.Problem("resource already exists")
+}
+
+// ForeignKey maps the foreign key constraint violations to 422: the request
+// references a missing resource, or deletes a referenced one.
+func ForeignKey(err error) *problem.Problem {
+	if !IsForeignKey(err) {
+		return nil
+	}
+	return apierr.UnprocessableEntity.Problem("related resource constraint violated")
+}
+
+// IsNotFound reports whether err is gorm.ErrRecordNotFound.
+func IsNotFound(err error) bool {
+	return anyMessage(err, func(msg string) bool { return msg == recordNotFoundMessage })
+}
+
+// IsDuplicateKey reports whether err is a unique constraint violation: gorm.ErrDuplicatedKey
+// (with TranslateError enabled) or a driver error.
+func IsDuplicateKey(err error) bool {
+	return anyMessage(err, containsAny(duplicateKeyMessages))
+}
+
+// IsForeignKey reports whether err is a foreign key constraint violation:
+// gorm.ErrForeignKeyViolated (with TranslateError enabled) or a driver error.
+func IsForeignKey(err error) bool {
+	return anyMessage(err, containsAny(foreignKeyMessages))
+}
+
+func containsAny(substrs []string) func(msg string) bool {
+	return func(msg string) bool {
+		for _, s := range substrs {
+			if strings.Contains(msg, s) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// anyMessage reports whether match is true for the message of an error in the tree of err.
+func anyMessage(err error, match func(msg string) bool) bool {
+	for err != nil {
+		if match(err.Error()) {
+			return true
+		}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range joined.Unwrap() {
+				if anyMessage(e, match) {
+					return true
+				}
+			}
+			return false
+		}
+		err = errors.Unwrap(err)
+	}
+	return false
+}
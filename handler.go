@@ -1,7 +1,9 @@
 package apierr
 
 import (
-	"errors"
+	"bytes"
+	"context"
+	"encoding/json"
 	"net/http"
 	"schneider.vip/problem"
 )
@@ -12,20 +14,23 @@ type DBNotFoundHandler func(err error) bool
 
 // DefaultDBNotFoundHandler override this function to correctly handle
 // Database errors
+//
+// Deprecated: register the handlers of the gormerr package, or Map the not found
+// error of the database driver.
 var DefaultDBNotFoundHandler DBNotFoundHandler = func(_ error) bool {
 	return false
 }
 
 // Handle err as a problem.Problem. The error is unwrapped recursively until is nil.
-// If a problem.Problem is not found, then return false, otherwise writes the response
-// and return true.
+// If a problem.Problem is not found, then the registered handlers are tried (see AddHandler),
+// followed by the sentinel errors mapped with Map. A *http.MaxBytesError is replied with
+// 413. The other request body failures wrapped in a BodyError (see BodyErr and DecodeJSON)
+// are replied with 400, the JSON decoding errors (*json.SyntaxError, *json.UnmarshalTypeError)
+// with the offending offset and field. The wrapping is required: an unwrapped JSON
+// decoding error is replied with 500 like the other unknown errors.
+// If none matches return false, otherwise writes the response and return true.
 func Handle(err error, w http.ResponseWriter) bool {
-	ae := extractProblem(err)
-	if ae == nil {
-		return false
-	}
-	_, _ = ae.WriteTo(w)
-	return true
+	return defaultRegistry.handle(context.Background(), err, w, nil)
 }
 
 // HandleISE executes Handle.
@@ -33,23 +38,95 @@ func Handle(err error, w http.ResponseWriter) bool {
 //
 // If the error is unknown (not a Problem nor a DBNotFoundErr) it will reply with Internal Server Error.
 func HandleISE(err error, w http.ResponseWriter) {
-	if Handle(err, w) {
-		return
-	}
-	if DefaultDBNotFoundHandler(err) {
-		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
-		return
-	}
-	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	defaultRegistry.handleISE(context.Background(), err, w, nil)
+}
+
+// HandleRequest is the request-aware version of Handle: the hooks bound to
+// the request context (e.g. WithDecorator) are applied and the problem is
+// localized according to the Accept-Language header (see RegisterTranslations).
+// The "instance" member of the problem is set to the request path, when missing
+// (see Registry.Instance).
+//
+// Errors caused by the request context, when not converted to a problem, are handled too:
+// for context.Canceled, when the request context is done, the client went away and
+// nothing is written, while context.DeadlineExceeded is replied with
+// DefaultDeadlineProblem (504).
+func HandleRequest(err error, w http.ResponseWriter, r *http.Request) bool {
+	return defaultRegistry.handle(r.Context(), err, w, r)
+}
+
+// HandleRequestISE is the request-aware version of HandleISE, see HandleRequest.
+func HandleRequestISE(err error, w http.ResponseWriter, r *http.Request) {
+	defaultRegistry.handleISE(r.Context(), err, w, r)
+}
+
+// HandleContext is the version of Handle for the transports passing the request
+// context without the request (e.g. go-kit): the hooks bound to ctx are applied, and
+// the errors caused by ctx are handled as by HandleRequest.
+func HandleContext(ctx context.Context, err error, w http.ResponseWriter) bool {
+	return defaultRegistry.handle(ctx, err, w, nil)
+}
+
+// HandleContextISE is the version of HandleISE for the transports passing the
+// request context without the request, see HandleContext.
+func HandleContextISE(ctx context.Context, err error, w http.ResponseWriter) {
+	defaultRegistry.handleISE(ctx, err, w, nil)
+}
+
+// problemData returns the members of p. The problem package does not expose
+// them, so they are read back from the JSON representation.
+func problemData(p *problem.Problem) map[string]any {
+	data := map[string]any{}
+	_ = json.Unmarshal(p.JSON(), &data)
+	return data
+}
+
+// problemMembers returns the members of p like problemData, but with the numbers
+// as json.Number: the problems rebuilt from them keep the integers beyond 2^53 exact.
+func problemMembers(p *problem.Problem) map[string]any {
+	data := map[string]any{}
+	dec := json.NewDecoder(bytes.NewReader(p.JSON()))
+	dec.UseNumber()
+	_ = dec.Decode(&data)
+	return data
+}
+
+// problemStatus returns the status member of p, 0 if missing.
+func problemStatus(p *problem.Problem) int {
+	status, _ := problemData(p)["status"].(float64)
+	return int(status)
 }
 
-func extractProblem(err error) *problem.Problem {
-	var ae *problem.Problem
-	for err != nil {
-		if errors.As(err, &ae) {
-			return ae
+// problemFromData is the inverse of problemData and problemMembers.
+func problemFromData(data map[string]any) *problem.Problem {
+	p := problem.New()
+	for k, v := range data {
+		if k == "status" {
+			switch status := v.(type) {
+			case float64:
+				v = int(status)
+			case json.Number:
+				if n, err := status.Int64(); err == nil {
+					v = int(n)
+				}
+			}
 		}
-		err = errors.Unwrap(err)
+		p.Append(problem.Custom(k, v))
+	}
+	return p
+}
+
+// cloneProblem returns a copy of p, including the wrapped error.
+func cloneProblem(p *problem.Problem) *problem.Problem {
+	c := problemFromData(problemMembers(p))
+	if reason := p.Unwrap(); reason != nil {
+		c.Append(problem.WrapSilent(reason))
 	}
-	return nil
+	return c
+}
+
+// finish records the outcome of the handling of an error.
+func finish(w http.ResponseWriter, o Outcome) {
+	recordOutcome(w, o)
+	countOutcome(o)
 }
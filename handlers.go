@@ -0,0 +1,83 @@
+package apierr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// NamedHandler is an ErrHandler registered with a name and a priority, see RegisterHandlers.
+type NamedHandler struct {
+	Name string
+	// Priority orders the handlers: higher priorities are tried first. Handlers of equal
+	// priority are tried in registration order, those of AddHandler having priority 0.
+	Priority int
+	Handler  ErrHandler
+	// Quarantined handlers are canaried: the problem they return is logged (see
+	// Registry.Logger) but discarded, and the next handlers are tried as if they were
+	// not registered, except for the Rollout percentage of the errors.
+	Quarantined bool
+	// Rollout is the percentage (0-100) of the errors converted by a quarantined handler.
+	Rollout float64
+}
+
+// RegisterHandlers registers handlers in the default registry, see Registry.RegisterHandlers.
+func RegisterHandlers(handlers []NamedHandler) error {
+	return defaultRegistry.RegisterHandlers(handlers)
+}
+
+// RegisterHandlers registers a set of handlers atomically, e.g. the mappings contributed by a module.
+// The handlers are validated first: each one must have a handler, a Rollout within
+// 0-100 and a name not already registered, in r or in handlers; on error none of
+// them is registered.
+// They are tried by decreasing Priority, then in the order of handlers.
+//
+// Example:
+//
+//	err := apierr.RegisterHandlers([]apierr.NamedHandler{
+//		{Name: "billing.card-declined", Priority: 10, Handler: cardDeclined},
+//		{Name: "billing.quota", Handler: quotaExceeded},
+//		// canary of a new mapping, applied to 5% of the matching errors
+//		{Name: "billing.card-declined-v2", Priority: 20, Handler: cardDeclinedV2, Quarantined: true, Rollout: 5},
+//	})
+func (r *Registry) RegisterHandlers(handlers []NamedHandler) error {
+	names := map[string]bool{}
+	for _, h := range r.handlers {
+		if h.Name != "" {
+			names[h.Name] = true
+		}
+	}
+	var errs []error
+	for i, h := range handlers {
+		switch {
+		case h.Name == "":
+			errs = append(errs, fmt.Errorf("handler %d: empty name", i))
+		case names[h.Name]:
+			errs = append(errs, fmt.Errorf("handler %q: already registered", h.Name))
+		}
+		if h.Handler == nil {
+			errs = append(errs, fmt.Errorf("handler %q: nil handler", h.Name))
+		}
+		if !(h.Rollout >= 0 && h.Rollout <= 100) {
+			errs = append(errs, fmt.Errorf("handler %q: rollout %v out of 0-100", h.Name, h.Rollout))
+		}
+		names[h.Name] = true
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	for _, h := range handlers {
+		r.insertHandler(h)
+	}
+	return nil
+}
+
+// insertHandler inserts h after the handlers with a greater or equal priority.
+func (r *Registry) insertHandler(h NamedHandler) {
+	i := len(r.handlers)
+	for i > 0 && r.handlers[i-1].Priority < h.Priority {
+		i--
+	}
+	r.handlers = append(r.handlers, NamedHandler{})
+	copy(r.handlers[i+1:], r.handlers[i:])
+	r.handlers[i] = h
+}
@@ -0,0 +1,126 @@
+package apierr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/debyten/apierr"
+	"schneider.vip/problem"
+)
+
+var errCard = errors.New("card declined")
+
+// titled returns a handler converting errCard to a problem titled title.
+func titled(title string) apierr.ErrHandler {
+	return func(err error) *problem.Problem {
+		if errors.Is(err, errCard) {
+			return apierr.PaymentRequired.Problem(title)
+		}
+		return nil
+	}
+}
+
+// handledTitle returns the title of the problem replied by r for err.
+func handledTitle(t *testing.T, r *apierr.Registry, err error) string {
+	t.Helper()
+	return handledTitleRequest(t, r, err, nil)
+}
+
+// handledTitleRequest returns the title of the problem replied by r for err and req,
+// handled without request when req is nil.
+func handledTitleRequest(t *testing.T, r *apierr.Registry, err error, req *http.Request) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	if req != nil {
+		r.HandleRequestISE(err, w, req)
+	} else {
+		r.HandleISE(err, w)
+	}
+	var body struct {
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Title
+}
+
+func TestRegisterHandlersOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		add      bool // AddHandler "added" first
+		handlers []apierr.NamedHandler
+		want     string
+	}{
+		{"priority", false, []apierr.NamedHandler{
+			{Name: "low", Priority: 1, Handler: titled("low")},
+			{Name: "high", Priority: 2, Handler: titled("high")},
+		}, "high"},
+		{"equal priority in registration order", false, []apierr.NamedHandler{
+			{Name: "first", Priority: 1, Handler: titled("first")},
+			{Name: "second", Priority: 1, Handler: titled("second")},
+		}, "first"},
+		{"AddHandler before equal priority", true, []apierr.NamedHandler{
+			{Name: "named", Handler: titled("named")},
+		}, "added"},
+		{"positive priority before AddHandler", true, []apierr.NamedHandler{
+			{Name: "named", Priority: 1, Handler: titled("named")},
+		}, "named"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := apierr.NewRegistry()
+			if tt.add {
+				r.AddHandler(titled("added"))
+			}
+			if err := r.RegisterHandlers(tt.handlers); err != nil {
+				t.Fatal(err)
+			}
+			if got := handledTitle(t, r, errCard); got != tt.want {
+				t.Errorf("title = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterHandlersValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []apierr.NamedHandler
+		handlers []apierr.NamedHandler
+		want     string
+	}{
+		{"empty name", nil, []apierr.NamedHandler{{Handler: titled("a")}}, "empty name"},
+		{"nil handler", nil, []apierr.NamedHandler{{Name: "a"}}, "nil handler"},
+		{"rollout above 100", nil, []apierr.NamedHandler{{Name: "a", Handler: titled("a"), Quarantined: true, Rollout: 150}}, "out of 0-100"},
+		{"negative rollout", nil, []apierr.NamedHandler{{Name: "a", Handler: titled("a"), Quarantined: true, Rollout: -1}}, "out of 0-100"},
+		{"NaN rollout", nil, []apierr.NamedHandler{{Name: "a", Handler: titled("a"), Quarantined: true, Rollout: math.NaN()}}, "out of 0-100"},
+		{"duplicate in set", nil, []apierr.NamedHandler{
+			{Name: "a", Handler: titled("a")},
+			{Name: "a", Priority: 1, Handler: titled("b")},
+		}, "already registered"},
+		{"duplicate of registered", []apierr.NamedHandler{{Name: "a", Handler: titled("a")}},
+			[]apierr.NamedHandler{{Name: "a", Handler: titled("b")}}, "already registered"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := apierr.NewRegistry()
+			if err := r.RegisterHandlers(tt.existing); err != nil {
+				t.Fatal(err)
+			}
+			err := r.RegisterHandlers(tt.handlers)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+			// none of the set is registered
+			if handled := r.Handle(errCard, httptest.NewRecorder()); handled != (tt.existing != nil) {
+				t.Errorf("handled = %v, want only by the existing handlers", handled)
+			}
+		})
+	}
+}
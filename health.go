@@ -0,0 +1,140 @@
+package apierr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"schneider.vip/problem"
+)
+
+// ChecksExtension is the problem extension listing the dependency checks of a HealthError.
+const ChecksExtension = "checks"
+
+// Check statuses.
+const (
+	CheckPass = "pass"
+	CheckFail = "fail"
+)
+
+// Check is the result of the check of a dependency.
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Output is the text of the error of a failed check.
+	Output string `json:"output,omitempty"`
+}
+
+// HealthError is a builder of 503 problems listing the checks of the dependencies of a
+// health or readiness endpoint. In ModeProduction the checks are redacted as the other
+// members of the server errors, unless ChecksExtension is added to SafeMembers.
+//
+// Example:
+//
+//	h := apierr.Health()
+//	h.Check("postgres", db.PingContext(ctx))
+//	h.Check("redis", rdb.Ping(ctx).Err())
+//	if err := h.Err(); err != nil {
+//		apierr.HandleRequest(err, w, r)
+//		return
+//	}
+type HealthError struct {
+	checks []Check
+	errs   []error
+}
+
+// Health returns an empty HealthError.
+func Health() *HealthError {
+	return &HealthError{}
+}
+
+// Check records the check of the dependency name: it failed when err is not nil.
+func (h *HealthError) Check(name string, err error) *HealthError {
+	c := Check{Name: name, Status: CheckPass}
+	if err != nil {
+		c.Status, c.Output = CheckFail, err.Error()
+		h.errs = append(h.errs, err)
+	}
+	h.checks = append(h.checks, c)
+	return h
+}
+
+// Checks returns the checks recorded so far.
+func (h *HealthError) Checks() []Check {
+	return h.checks
+}
+
+// Err returns h if a check failed, nil otherwise.
+func (h *HealthError) Err() error {
+	if len(h.errs) == 0 {
+		return nil
+	}
+	return h
+}
+
+func (h *HealthError) Error() string {
+	var failed []string
+	for _, c := range h.checks {
+		if c.Status == CheckFail {
+			failed = append(failed, c.Name)
+		}
+	}
+	return "unhealthy: " + strings.Join(failed, ", ")
+}
+
+// Unwrap returns the errors of the failed checks.
+func (h *HealthError) Unwrap() []error {
+	return h.errs
+}
+
+// Problem converts h to a 503 problem with the ChecksExtension.
+func (h *HealthError) Problem() *problem.Problem {
+	return ServiceUnavailable.Problem("service unhealthy").Append(problem.Custom(ChecksExtension, h.checks))
+}
+
+// HealthHandler returns a health endpoint using the default registry, see Registry.HealthHandler.
+func HealthHandler(checks map[string]func(ctx context.Context) error) http.Handler {
+	return defaultRegistry.HealthHandler(checks)
+}
+
+// HealthHandler returns a health endpoint running checks concurrently, by dependency name.
+// When all of them pass it replies 200 with {"status": "pass", "checks": [...]}, otherwise
+// the HealthError is handled by r. The checks are listed by name.
+//
+// Example:
+//
+//	mux.Handle("GET /readyz", apierr.HealthHandler(map[string]func(context.Context) error{
+//		"postgres": db.PingContext,
+//	}))
+func (r *Registry) HealthHandler(checks map[string]func(ctx context.Context) error) http.Handler {
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		errs := make([]error, len(names))
+		var wg sync.WaitGroup
+		for i, name := range names {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = checks[name](req.Context())
+			}()
+		}
+		wg.Wait()
+		h := Health()
+		for i, name := range names {
+			h.Check(name, errs[i])
+		}
+		if err := h.Err(); err != nil {
+			r.HandleRequestISE(err, w, req)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"status": CheckPass, "checks": h.checks})
+	})
+}
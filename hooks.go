@@ -0,0 +1,39 @@
+package apierr
+
+import (
+	"context"
+)
+
+// ContextNotifier is a Notifier supporting cancellation: when the registry has a
+// HookTimeout, NotifyContext is called instead of Notify with a context canceled
+// at the timeout.
+type ContextNotifier interface {
+	Notifier
+	NotifyContext(ctx context.Context, n Notification)
+}
+
+// runHook runs fn, calling an external dependency, bounded by r.HookTimeout: once the
+// timeout expires the context of fn is canceled and the handling of the error goes on
+// without waiting for fn. The timeouts are counted in the "hook_timeouts" expvar
+// counter and reported to r.OnHookTimeout.
+func (r *Registry) runHook(ctx context.Context, hook string, fn func(ctx context.Context)) {
+	if r.HookTimeout <= 0 {
+		fn(ctx)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.HookTimeout)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(ctx)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errorCounters.Add("hook_timeouts", 1)
+		if r.OnHookTimeout != nil {
+			r.OnHookTimeout(hook, r.HookTimeout)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package apierr
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"io/fs"
+	"strconv"
+	"sync"
+
+	"schneider.vip/problem"
+)
+
+// LocalizedEncoder is an Encoder rendering problems differently per language.
+// The request-aware functions (HandleRequest...) call EncodeLocalized with the
+// languages accepted by the request, by decreasing preference, ending with
+// Registry.DefaultLocale.
+type LocalizedEncoder interface {
+	Encoder
+	EncodeLocalized(w io.Writer, langs []string, p *problem.Problem) error
+}
+
+// defaultHTMLPage is the page rendered when FS has no template for a problem.
+var defaultHTMLPage = template.Must(template.New("").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.status}} {{.title}}</title></head>
+<body><h1>{{.title}}</h1>{{with .detail}}<p>{{.}}</p>{{end}}</body></html>
+`))
+
+// HTMLEncoder encodes problems as text/html error pages, rendered with the html/template
+// templates of an fs.FS, e.g. an embed.FS shipping branded pages inside the binary.
+//
+// The template of a problem is the first found among <status>.<lang>.html and
+// <status>.html, then error.<lang>.html and error.html, trying the languages of
+// the request in order. The templates are executed with the members of the problem
+// ({{.status}}, {{.title}}, {{.detail}}...). A built-in page is rendered when none
+// is found or the template fails.
+//
+// Example:
+//
+//	//go:embed errors
+//	var pages embed.FS
+//
+//	sub, _ := fs.Sub(pages, "errors")
+//	apierr.RegisterEncoder(apierr.NewHTMLEncoder(sub, false))
+type HTMLEncoder struct {
+	fsys   fs.FS
+	reload bool
+
+	mu        sync.Mutex
+	templates map[string]*template.Template
+}
+
+// NewHTMLEncoder returns an HTMLEncoder rendering the templates of fsys. When reload is
+// true the templates are parsed on every error, so that the changes of an os.DirFS show
+// up without a restart in development; otherwise they are parsed once and cached.
+func NewHTMLEncoder(fsys fs.FS, reload bool) *HTMLEncoder {
+	return &HTMLEncoder{fsys: fsys, reload: reload, templates: map[string]*template.Template{}}
+}
+
+func (*HTMLEncoder) ContentType() string { return "text/html; charset=utf-8" }
+
+// Encode renders p with the templates not bound to a language.
+func (e *HTMLEncoder) Encode(w io.Writer, p *problem.Problem) error {
+	return e.EncodeLocalized(w, nil, p)
+}
+
+// EncodeLocalized renders p with the template of the first of langs having one.
+func (e *HTMLEncoder) EncodeLocalized(w io.Writer, langs []string, p *problem.Problem) error {
+	data := problemData(p)
+	t := e.lookup(strconv.Itoa(problemStatus(p)), langs)
+	if t == nil {
+		t = e.lookup("error", langs)
+	}
+	if t != nil {
+		// rendered aside, so that a template failing midway writes no partial page
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err == nil {
+			_, err = buf.WriteTo(w)
+			return err
+		}
+	}
+	return defaultHTMLPage.Execute(w, data)
+}
+
+// lookup returns the template of base in the first of langs having one, falling
+// back to the template without language. It returns nil if there is none.
+func (e *HTMLEncoder) lookup(base string, langs []string) *template.Template {
+	for _, lang := range langs {
+		if t := e.template(base + "." + lang + ".html"); t != nil {
+			return t
+		}
+	}
+	return e.template(base + ".html")
+}
+
+// template returns the parsed template of the file name, nil if it does not exist or
+// cannot be parsed. Only the parsed templates are cached, unless reloading: the names
+// derive from the languages of the requests, which must not grow the cache.
+func (e *HTMLEncoder) template(name string) *template.Template {
+	if !e.reload {
+		e.mu.Lock()
+		t, ok := e.templates[name]
+		e.mu.Unlock()
+		if ok {
+			return t
+		}
+	}
+	text, err := fs.ReadFile(e.fsys, name)
+	if err != nil {
+		return nil
+	}
+	t, err := template.New(name).Parse(string(text))
+	if err != nil {
+		// a broken template is not cached, so that it can be fixed in place
+		return nil
+	}
+	if !e.reload {
+		e.mu.Lock()
+		e.templates[name] = t
+		e.mu.Unlock()
+	}
+	return t
+}
+
+// localizedEncoder binds a LocalizedEncoder to the languages of a request.
+type localizedEncoder struct {
+	LocalizedEncoder
+	langs []string
+}
+
+func (e localizedEncoder) Encode(w io.Writer, p *problem.Problem) error {
+	return e.EncodeLocalized(w, e.langs, p)
+}
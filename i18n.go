@@ -0,0 +1,225 @@
+package apierr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"schneider.vip/problem"
+)
+
+// Message is the localized title and detail of a problem. They are text/template
+// templates executed with the problem members, e.g. "limit of {{.limit}} reached".
+type Message struct {
+	Title  string
+	Detail string
+}
+
+// Localizer localizes the problem identified by code (see RegisterTranslations) in lang.
+// data are the members of the problem.
+type Localizer interface {
+	Localize(ctx context.Context, lang, code string, data map[string]any) (Message, bool)
+}
+
+type translation struct {
+	title, detail *template.Template
+}
+
+// RegisterTranslations registers the messages of lang in the default registry, see Registry.RegisterTranslations.
+func RegisterTranslations(lang string, messages map[string]Message) error {
+	return defaultRegistry.RegisterTranslations(lang, messages)
+}
+
+// RegisterTranslations registers the messages of lang, by problem code: the code of an
+// APIErr (see APIErr.WithCode) or the problem type. The request-aware functions
+// (HandleRequest...) pick the language with the Accept-Language header, falling back to
+// Registry.DefaultLocale; the problems without a translation are left unchanged.
+//
+// Example:
+//
+//	err := apierr.RegisterTranslations("it", map[string]apierr.Message{
+//		"user_not_found": {Title: "Utente non trovato"},
+//		"quota_exceeded": {Title: "Quota superata", Detail: "Limite di {{.limit}} richieste raggiunto"},
+//	})
+func (r *Registry) RegisterTranslations(lang string, messages map[string]Message) error {
+	parsed := make(map[string]translation, len(messages))
+	for code, m := range messages {
+		var t translation
+		var err error
+		if t.title, err = parseMessage(m.Title); err != nil {
+			return fmt.Errorf("translation %s %s: %w", lang, code, err)
+		}
+		if t.detail, err = parseMessage(m.Detail); err != nil {
+			return fmt.Errorf("translation %s %s: %w", lang, code, err)
+		}
+		parsed[code] = t
+	}
+	if r.translations == nil {
+		r.translations = map[string]map[string]translation{}
+	}
+	lang = strings.ToLower(lang)
+	if r.translations[lang] == nil {
+		r.translations[lang] = map[string]translation{}
+	}
+	for code, t := range parsed {
+		r.translations[lang][code] = t
+	}
+	return nil
+}
+
+// validateTranslations returns an error for every code missing in a language
+// while translated in another one.
+func (r *Registry) validateTranslations() []error {
+	codes := map[string]bool{}
+	for _, messages := range r.translations {
+		for code := range messages {
+			codes[code] = true
+		}
+	}
+	var errs []error
+	for _, lang := range sortedKeys(r.translations) {
+		for _, code := range sortedKeys(codes) {
+			if _, ok := r.translations[lang][code]; !ok {
+				errs = append(errs, fmt.Errorf("code %q has no %q translation", code, lang))
+			}
+		}
+	}
+	return errs
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func parseMessage(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	return template.New("").Option("missingkey=zero").Parse(text)
+}
+
+// Localize implements Localizer with the registered translations.
+func (r *Registry) Localize(_ context.Context, lang, code string, data map[string]any) (Message, bool) {
+	t, ok := r.translations[lang][code]
+	if !ok {
+		return Message{}, false
+	}
+	var m Message
+	var err error
+	if m.Title, err = execMessage(t.title, data); err != nil {
+		return Message{}, false
+	}
+	if m.Detail, err = execMessage(t.detail, data); err != nil {
+		return Message{}, false
+	}
+	return m, true
+}
+
+func execMessage(t *template.Template, data map[string]any) (string, error) {
+	if t == nil {
+		return "", nil
+	}
+	var sb strings.Builder
+	err := t.Execute(&sb, data)
+	return sb.String(), err
+}
+
+func (r *Registry) localizer() Localizer {
+	if r.Localizer != nil {
+		return r.Localizer
+	}
+	return r
+}
+
+// localize translates the title and detail of p in the language requested by req.
+func (r *Registry) localize(ctx context.Context, w http.ResponseWriter, req *http.Request, err error, p *problem.Problem) *problem.Problem {
+	if req == nil || (r.Localizer == nil && len(r.translations) == 0) {
+		return p
+	}
+	data := problemData(p)
+	code := problemCode(err, data)
+	if code == "" {
+		return p
+	}
+	for _, lang := range r.languages(req) {
+		result := make(chan Message, 1)
+		r.runHook(ctx, "localizer", func(ctx context.Context) {
+			if m, ok := r.localizer().Localize(ctx, lang, code, data); ok {
+				result <- m
+			}
+		})
+		select {
+		case m := <-result:
+			w.Header().Set("Content-Language", lang)
+			p = cloneProblem(p)
+			if m.Title != "" {
+				p.Append(problem.Title(m.Title))
+			}
+			if m.Detail != "" {
+				p.Append(problem.Detail(m.Detail))
+			}
+			return p
+		default:
+		}
+	}
+	return p
+}
+
+// problemCode returns the code identifying the problem for translations:
+// the APIErr code, if any, or the problem type.
+func problemCode(err error, data map[string]any) string {
+	var e *APIErr
+	if errors.As(err, &e) && e.code != "" {
+		return e.code
+	}
+	typ, _ := data["type"].(string)
+	return typ
+}
+
+// languages returns the languages accepted by req by decreasing quality, each one followed
+// by its base language (en-US, en), and finally the default locale.
+func (r *Registry) languages(req *http.Request) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+	var accepted []weighted
+	for _, part := range strings.Split(req.Header.Get("Accept-Language"), ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			accepted = append(accepted, weighted{lang: strings.ToLower(lang), q: q})
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].q > accepted[j].q })
+	var langs []string
+	for _, a := range accepted {
+		langs = append(langs, a.lang)
+		if base, _, ok := strings.Cut(a.lang, "-"); ok {
+			langs = append(langs, base)
+		}
+	}
+	if r.DefaultLocale != "" {
+		langs = append(langs, strings.ToLower(r.DefaultLocale))
+	}
+	return langs
+}
@@ -0,0 +1,80 @@
+package apierr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/debyten/apierr"
+)
+
+func TestLocalization(t *testing.T) {
+	r := apierr.NewRegistry()
+	for lang, title := range map[string]string{"en": "User not found", "it": "Utente non trovato", "pt-br": "Usuário não encontrado"} {
+		if err := r.RegisterTranslations(lang, map[string]apierr.Message{"user_not_found": {Title: title}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.RegisterTranslations("it", map[string]apierr.Message{
+		"quota_exceeded": {Title: "Quota superata", Detail: "Limite di {{.limit}} richieste raggiunto"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	notFound := apierr.NotFound.Err(errors.New("no user 42")).WithCode("user_not_found")
+	tests := []struct {
+		name     string
+		accept   string
+		err      error
+		title    string
+		detail   string
+		language string
+	}{
+		{"exact language", "it", notFound, "Utente non trovato", "no user 42", "it"},
+		{"by quality", "fr;q=0.9, it;q=0.5, de", notFound, "Utente non trovato", "no user 42", "it"},
+		{"base language", "it-CH", notFound, "Utente non trovato", "no user 42", "it"},
+		{"region", "pt-BR", notFound, "Usuário não encontrado", "no user 42", "pt-br"},
+		{"default locale", "fr", notFound, "User not found", "no user 42", "en"},
+		{"no header", "", notFound, "User not found", "no user 42", "en"},
+		{"refused language", "it;q=0, fr", notFound, "User not found", "no user 42", "en"},
+		{"invalid quality", "it;q=x, fr", notFound, "User not found", "no user 42", "en"},
+		{"template", "it", apierr.TooManyRequests.Err(errors.New("quota")).WithCode("quota_exceeded").WithExtension("limit", 100), "Quota superata", "Limite di 100 richieste raggiunto", "it"},
+		{"no translation", "it", apierr.Conflict.Problem("conflict"), "conflict", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Language", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			r.HandleRequestISE(tt.err, w, req)
+			var body struct {
+				Title  string `json:"title"`
+				Detail string `json:"detail"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Title != tt.title || body.Detail != tt.detail {
+				t.Errorf("title, detail = %q, %q, want %q, %q", body.Title, body.Detail, tt.title, tt.detail)
+			}
+			if got := w.Header().Get("Content-Language"); got != tt.language {
+				t.Errorf("Content-Language = %q, want %q", got, tt.language)
+			}
+		})
+	}
+}
+
+func TestLocalizationNeedsRequest(t *testing.T) {
+	r := apierr.NewRegistry()
+	if err := r.RegisterTranslations("en", map[string]apierr.Message{"user_not_found": {Title: "User not found"}}); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	r.HandleISE(apierr.NotFound.Err(errors.New("no user 42")).WithCode("user_not_found"), w)
+	if got := w.Header().Get("Content-Language"); got != "" {
+		t.Errorf("Content-Language = %q, want none without request", got)
+	}
+}
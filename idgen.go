@@ -0,0 +1,58 @@
+package apierr
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// IDGenerator generates the identifiers of the error occurrences (problem instances,
+// notifications), e.g. to use ULIDs or snowflake ids, or deterministic ids in tests.
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDGeneratorFunc is an adapter to allow the use of ordinary functions as IDGenerator.
+type IDGeneratorFunc func() string
+
+// NewID calls f().
+func (f IDGeneratorFunc) NewID() string {
+	return f()
+}
+
+// DefaultIDGenerator is the IDGenerator used when Registry.IDGenerator is nil.
+var DefaultIDGenerator IDGenerator = UUIDv7{}
+
+// UUIDv7 generates time-ordered UUIDs (RFC 9562, version 7).
+type UUIDv7 struct{}
+
+// NewID returns a new UUIDv7 in its canonical text form.
+func (UUIDv7) NewID() string {
+	var u [16]byte
+	_, _ = rand.Read(u[6:])
+	ms := uint64(time.Now().UnixMilli())
+	for i := 5; i >= 0; i-- {
+		u[i] = byte(ms)
+		ms >>= 8
+	}
+	u[6] = u[6]&0x0f | 0x70 // version 7
+	u[8] = u[8]&0x3f | 0x80 // variant 10
+	var s [36]byte
+	hex.Encode(s[0:8], u[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], u[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], u[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], u[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], u[10:])
+	return string(s[:])
+}
+
+func (r *Registry) idGenerator() IDGenerator {
+	if r.IDGenerator != nil {
+		return r.IDGenerator
+	}
+	return DefaultIDGenerator
+}
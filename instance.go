@@ -0,0 +1,43 @@
+package apierr
+
+import (
+	"net/http"
+
+	"schneider.vip/problem"
+)
+
+// RequestIDInstance returns a Registry.Instance function identifying the occurrence of
+// a problem with the request path and the request ID found in header, as path#id.
+// When the request has no ID, one is generated with DefaultIDGenerator.
+//
+// Example:
+//
+//	apierr.DefaultRegistry().Instance = apierr.RequestIDInstance("X-Request-Id")
+func RequestIDInstance(header string) func(req *http.Request) string {
+	return func(req *http.Request) string {
+		id := req.Header.Get(header)
+		if id == "" {
+			id = DefaultIDGenerator.NewID()
+		}
+		return req.URL.Path + "#" + id
+	}
+}
+
+// applyInstance sets the "instance" member of p, on a copy, when the error is handled
+// for a request and p has none.
+func (r *Registry) applyInstance(req *http.Request, p *problem.Problem) *problem.Problem {
+	if req == nil {
+		return p
+	}
+	if instance, _ := problemData(p)["instance"].(string); instance != "" {
+		return p
+	}
+	instance := req.URL.Path
+	if r.Instance != nil {
+		instance = r.Instance(req)
+	}
+	if instance == "" {
+		return p
+	}
+	return cloneProblem(p).Append(problem.Instance(instance))
+}
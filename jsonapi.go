@@ -0,0 +1,142 @@
+package apierr
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"schneider.vip/problem"
+)
+
+// JSONAPIEncoder encodes problems as JSON:API error documents (application/vnd.api+json):
+// an "errors" array of error objects. The violations of a ValidationError and the causes
+// of Aggregate are listed as distinct objects, the violations pointing to their field
+// with source.pointer (/data/attributes/<field>).
+//
+// The code of an object is the ID of the registered code of the problem (see Register)
+// or its type, and its id is the trace id, if any, or the problem instance.
+//
+// Example:
+//
+//	registry.SetDefaultEncoder(apierr.JSONAPIEncoder{Registry: registry})
+type JSONAPIEncoder struct {
+	// Registry is the registry whose codes are recognized in the type URIs, the
+	// default registry when nil.
+	Registry *Registry
+}
+
+// JSONAPIError is a JSON:API error object.
+type JSONAPIError struct {
+	ID     string         `json:"id,omitempty"`
+	Status string         `json:"status,omitempty"`
+	Code   string         `json:"code,omitempty"`
+	Title  string         `json:"title,omitempty"`
+	Detail string         `json:"detail,omitempty"`
+	Source *JSONAPISource `json:"source,omitempty"`
+	Meta   map[string]any `json:"meta,omitempty"`
+}
+
+// JSONAPISource locates the cause of a JSON:API error in the request document.
+type JSONAPISource struct {
+	Pointer string `json:"pointer,omitempty"`
+}
+
+func (JSONAPIEncoder) ContentType() string { return "application/vnd.api+json" }
+
+func (e JSONAPIEncoder) Encode(w io.Writer, p *problem.Problem) error {
+	r := e.Registry
+	if r == nil {
+		r = defaultRegistry
+	}
+	return json.NewEncoder(w).Encode(map[string]any{"errors": r.jsonAPIErrors(problemData(p))})
+}
+
+// JSONAPIErrors returns the JSON:API error objects of p, recognizing the codes of the default registry.
+func JSONAPIErrors(p *problem.Problem) []JSONAPIError {
+	return defaultRegistry.jsonAPIErrors(problemData(p))
+}
+
+func (r *Registry) jsonAPIErrors(data map[string]any) []JSONAPIError {
+	base := r.jsonAPIError(data)
+	var objects []JSONAPIError
+	if violations, ok := data[ValidationExtension].([]any); ok {
+		for _, v := range violations {
+			vm, _ := v.(map[string]any)
+			o := base
+			o.Detail, _ = vm["message"].(string)
+			if code, _ := vm["code"].(string); code != "" {
+				o.Code = code
+			}
+			if field, _ := vm["field"].(string); field != "" {
+				o.Source = &JSONAPISource{Pointer: "/data/attributes/" + jsonPointerEscaper.Replace(field)}
+			}
+			objects = append(objects, o)
+		}
+	}
+	if causes, ok := data[CausesExtension].([]any); ok {
+		for _, c := range causes {
+			if cm, ok := c.(map[string]any); ok {
+				objects = append(objects, r.jsonAPIErrors(cm)...)
+			}
+		}
+	}
+	if len(objects) == 0 {
+		objects = append(objects, base)
+	}
+	return objects
+}
+
+// jsonPointerEscaper escapes a JSON Pointer reference token (RFC 6901).
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// jsonAPIError returns the error object of the members of a problem. The members
+// not mapped to a field of the object are kept in its meta.
+func (r *Registry) jsonAPIError(data map[string]any) JSONAPIError {
+	var o JSONAPIError
+	if status, ok := data["status"].(float64); ok {
+		o.Status = strconv.Itoa(int(status))
+	}
+	o.Title, _ = data["title"].(string)
+	o.Detail, _ = data["detail"].(string)
+	if typ, _ := data["type"].(string); typ != "" && typ != "about:blank" {
+		o.Code = typ
+		if id, ok := r.codeID(typ); ok {
+			o.Code = id
+		}
+	}
+	o.ID, _ = data[TraceIDExtension].(string)
+	if o.ID == "" {
+		o.ID, _ = data["instance"].(string)
+	}
+	for k, v := range data {
+		switch k {
+		case "type", "status", "title", "detail", "instance", TraceIDExtension, ValidationExtension, CausesExtension:
+			continue
+		}
+		if o.Meta == nil {
+			o.Meta = map[string]any{}
+		}
+		o.Meta[k] = v
+	}
+	return o
+}
+
+// SetDefaultEncoder registers e in the default registry as its default encoder, see Registry.SetDefaultEncoder.
+func SetDefaultEncoder(e Encoder) {
+	defaultRegistry.SetDefaultEncoder(e)
+}
+
+// SetDefaultEncoder registers e (see RegisterEncoder) and makes it the encoder used
+// by Handle and HandleISE and by the requests not accepting any registered media type,
+// e.g. to reply JSON:API documents by default.
+func (r *Registry) SetDefaultEncoder(e Encoder) {
+	r.RegisterEncoder(e)
+	for i, enc := range r.encoders {
+		if enc.ContentType() == e.ContentType() {
+			copy(r.encoders[1:i+1], r.encoders[:i])
+			r.encoders[0] = e
+			return
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Package kitadapter plugs apierr in the HTTP transport of go-kit (github.com/go-kit/kit).
+//
+// go-kit error encoders are plain functions, hence this package does not depend on go-kit.
+//
+// Example:
+//
+//	handler := httptransport.NewServer(endpoint, decode, encode,
+//		httptransport.ServerErrorEncoder(kitadapter.ErrorEncoder()),
+//	)
+package kitadapter
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/debyten/apierr"
+)
+
+// ErrorEncoder returns an httptransport.ErrorEncoder writing the errors through the
+// default registry, see RegistryErrorEncoder.
+func ErrorEncoder() func(ctx context.Context, err error, w http.ResponseWriter) {
+	return RegistryErrorEncoder(apierr.DefaultRegistry())
+}
+
+// RegistryErrorEncoder returns an httptransport.ErrorEncoder writing err as a problem
+// through the handlers, decorators and hooks of r. The errors not converted to a
+// problem are replied as in Registry.HandleISE.
+//
+// The hooks bound to the request context (e.g. apierr.WithDecorator in a go-kit
+// ServerBefore function) are applied.
+func RegistryErrorEncoder(r *apierr.Registry) func(ctx context.Context, err error, w http.ResponseWriter) {
+	return func(ctx context.Context, err error, w http.ResponseWriter) {
+		r.HandleContextISE(ctx, err, w)
+	}
+}
@@ -0,0 +1,38 @@
+package apierr
+
+import (
+	"schneider.vip/problem"
+)
+
+// LinksExtension is the problem extension carrying the recovery actions available to the client.
+const LinksExtension = "links"
+
+// Link is a recovery action the client can take after an error.
+type Link struct {
+	// Rel identifies the action, e.g. "reauthenticate", "upgrade-plan".
+	Rel    string `json:"rel"`
+	Href   string `json:"href"`
+	Method string `json:"method,omitempty"`
+}
+
+// Links is a builder of the LinksExtension.
+//
+// Example:
+//
+//	apierr.Forbidden.Problem("plan limit reached").Append(
+//		apierr.Links{}.
+//			Add("upgrade-plan", "/billing/plans", http.MethodGet).
+//			Add("contact-sales", "https://example.com/sales", "").
+//			Option(),
+//	)
+type Links []Link
+
+// Add returns l with a new Link appended.
+func (l Links) Add(rel, href, method string) Links {
+	return append(l, Link{Rel: rel, Href: href, Method: method})
+}
+
+// Option returns the problem.Option setting the LinksExtension to l.
+func (l Links) Option() problem.Option {
+	return problem.Custom(LinksExtension, l)
+}
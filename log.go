@@ -0,0 +1,57 @@
+package apierr
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// SetLogger sets the logger of the default registry, see Registry.Logger.
+//
+// Example:
+//
+//	apierr.SetLogger(slog.Default())
+func SetLogger(l *slog.Logger) {
+	defaultRegistry.Logger = l
+}
+
+// log logs a handled error: server errors at error level and, when LogClientErrors
+// is set, client errors at debug level.
+func (r *Registry) log(ctx context.Context, req *http.Request, err error, status int, title, typ string) {
+	if r.Logger == nil {
+		return
+	}
+	level := slog.LevelError
+	if status < 500 {
+		if !r.LogClientErrors {
+			return
+		}
+		level = slog.LevelDebug
+	}
+	if !r.Logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := []slog.Attr{slog.Int("status", status), slog.String("title", title)}
+	if typ != "" {
+		attrs = append(attrs, slog.String("type", typ))
+	}
+	if req != nil {
+		attrs = append(attrs, slog.String("method", req.Method), slog.String("path", req.URL.Path))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("errors", r.renderCauses(err, DefaultCauseChainLimits)))
+	}
+	r.Logger.LogAttrs(ctx, level, "request failed", attrs...)
+}
+
+// logExtensionErrs logs at warn level the values rejected by APIErr.WithExtension.
+func (r *Registry) logExtensionErrs(ctx context.Context, err error) {
+	var ae *APIErr
+	if r.Logger == nil || !errors.As(err, &ae) {
+		return
+	}
+	if extErr := ae.ExtensionErr(); extErr != nil {
+		r.Logger.LogAttrs(ctx, slog.LevelWarn, "extension rejected", slog.String("error", extErr.Error()))
+	}
+}
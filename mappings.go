@@ -0,0 +1,134 @@
+package apierr
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Mapping is a row of the error mapping documentation: how an error is replied.
+type Mapping struct {
+	// Error describes the Go error: a registered code or the type and text of a sample error.
+	Error string
+	// Handler is the name of the handler converting the error, empty when the
+	// error carries its problem (APIErr, problem.Problem...).
+	Handler string
+	Status  int
+	Code    string
+	Title   string
+	Type    string
+}
+
+// Mappings returns the mappings of the default registry, see Registry.Mappings.
+func Mappings(samples ...error) []Mapping {
+	return defaultRegistry.Mappings(samples...)
+}
+
+// Mappings documents how errors are replied: a row for each registered code (see Register),
+// one for each sentinel of Map and each sample error handled by r, and one for each
+// named handler not matched by any of them (whose status is unknown, hence 0).
+// Samples not handled are omitted.
+//
+// The errors are converted as by Handle, except for the quarantined handlers, which are
+// documented as inactive: their rows are marked "(quarantined)".
+// The samples are typically the sentinel and typed errors of the service.
+func (r *Registry) Mappings(samples ...error) []Mapping {
+	var ms []Mapping
+	for _, c := range r.Codes() {
+		ms = append(ms, Mapping{Error: "code " + c.ID, Status: int(c.Status), Code: c.ID, Title: c.Title, Type: c.TypeURI()})
+	}
+	matched := map[string]bool{}
+	sentinels := r.sentinelErrors()
+	for _, err := range samples {
+		if !slices.Contains(sentinels, err) {
+			sentinels = append(sentinels, err)
+		}
+	}
+	for _, err := range sentinels {
+		m, ok := r.mapping(err)
+		if !ok {
+			continue
+		}
+		matched[m.Handler] = true
+		ms = append(ms, m)
+	}
+	for _, h := range r.handlers {
+		if h.Name == "" || matched[h.Name] {
+			continue
+		}
+		m := Mapping{Error: "?", Handler: h.Name}
+		if h.Quarantined {
+			m.Handler += " (quarantined)"
+		}
+		ms = append(ms, m)
+	}
+	return ms
+}
+
+func (r *Registry) mapping(err error) (Mapping, bool) {
+	m := Mapping{Error: fmt.Sprintf("%T: %v", err, err)}
+	p, _ := r.findProblem(err)
+	if p == nil {
+		p, m.Handler = r.convertError(err, activeHandler)
+	}
+	if p == nil {
+		return Mapping{}, false
+	}
+	data := problemData(p)
+	m.Status = problemStatus(p)
+	m.Title, _ = data["title"].(string)
+	m.Type, _ = data["type"].(string)
+	m.Code = problemCode(err, nil)
+	return m, true
+}
+
+// WriteMappingsMarkdown writes ms as a markdown table.
+func WriteMappingsMarkdown(w io.Writer, ms []Mapping) error {
+	var sb strings.Builder
+	sb.WriteString("| Error | Handler | Status | Code | Title | Type |\n")
+	sb.WriteString("|---|---|---|---|---|---|\n")
+	for _, m := range ms {
+		status := ""
+		if m.Status != 0 {
+			status = fmt.Sprint(m.Status)
+		}
+		cells := []string{m.Error, m.Handler, status, m.Code, m.Title, m.Type}
+		for i, c := range cells {
+			cells[i] = strings.NewReplacer("|", `\|`, "\n", " ").Replace(c)
+		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+var mappingsPage = template.Must(template.New("").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Error mappings</title></head>
+<body><table>
+<tr><th>Error</th><th>Handler</th><th>Status</th><th>Code</th><th>Title</th><th>Type</th></tr>
+{{range .}}<tr><td>{{.Error}}</td><td>{{.Handler}}</td><td>{{if .Status}}{{.Status}}{{end}}</td><td>{{.Code}}</td><td>{{.Title}}</td><td>{{.Type}}</td></tr>
+{{end}}</table></body></html>
+`))
+
+// MappingsHandler serves the mappings of the default registry as an HTML table, or as
+// markdown when the request accepts text/markdown, as living documentation for support engineers.
+// The mappings are computed on each request, so that they reflect the current registry.
+//
+// Example:
+//
+//	mux.Handle("/internal/errors", apierr.MappingsHandler(sql.ErrNoRows, io.ErrUnexpectedEOF, store.ErrConflict))
+func MappingsHandler(samples ...error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ms := Mappings(samples...)
+		if strings.Contains(r.Header.Get("Accept"), "text/markdown") {
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			_ = WriteMappingsMarkdown(w, ms)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = mappingsPage.Execute(w, ms)
+	})
+}
@@ -0,0 +1,40 @@
+package apierr
+
+import (
+	"errors"
+
+	"schneider.vip/problem"
+)
+
+// MapType registers in the default registry a handler converting the errors of type T
+// with fn, see TypeHandler.
+//
+// Example:
+//
+//	apierr.MapType(func(e *pq.Error) *problem.Problem {
+//		if e.Code == "23505" {
+//			return apierr.Conflict.Problem("already exists")
+//		}
+//		return nil
+//	})
+func MapType[T error](fn func(T) *problem.Problem) {
+	defaultRegistry.AddHandler(TypeHandler(fn))
+}
+
+// TypeHandler returns an ErrHandler applying fn to the first error of type T in the
+// chain of err (errors.As). fn can return nil to leave err to the next handlers.
+//
+// Example:
+//
+//	registry.AddHandler(apierr.TypeHandler(func(e *mysql.MySQLError) *problem.Problem {
+//		// ...
+//	}))
+func TypeHandler[T error](fn func(T) *problem.Problem) ErrHandler {
+	return func(err error) *problem.Problem {
+		var target T
+		if !errors.As(err, &target) {
+			return nil
+		}
+		return fn(target)
+	}
+}
@@ -0,0 +1,61 @@
+package apierr
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"schneider.vip/problem"
+)
+
+// Reply headers written by EncodeReply. They follow the NATS micro conventions,
+// so that clients not aware of problems can still read the error.
+const (
+	ReplyErrorHeader     = "Nats-Service-Error"
+	ReplyErrorCodeHeader = "Nats-Service-Error-Code"
+)
+
+// EncodeReply serializes p for request/reply messaging (NATS, AMQP...).
+// The headers carry the problem content type, status and title; the payload is the problem JSON.
+// The returned header can be converted to nats.Header.
+//
+// Example:
+//
+//	h, body := apierr.EncodeReply(p)
+//	_ = msg.RespondMsg(&nats.Msg{Header: nats.Header(h), Data: body})
+func EncodeReply(p *problem.Problem) (http.Header, []byte) {
+	data := problemData(p)
+	h := http.Header{}
+	h.Set("Content-Type", problem.ContentTypeJSON)
+	if status, ok := data["status"].(float64); ok {
+		h.Set(ReplyErrorCodeHeader, strconv.Itoa(int(status)))
+	}
+	if title, ok := data["title"].(string); ok {
+		h.Set(ReplyErrorHeader, title)
+	}
+	return h, p.JSON()
+}
+
+// DecodeReply is the counterpart of EncodeReply: it returns the problem carried by a reply,
+// or false when the reply is not an error. Replies having only the error headers
+// (e.g. produced by NATS micro services) are converted to a problem as well.
+func DecodeReply(h http.Header, body []byte) (*problem.Problem, bool) {
+	if h.Get("Content-Type") == problem.ContentTypeJSON {
+		data := map[string]any{}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&data); err == nil {
+			return problemFromData(data), true
+		}
+	}
+	code, err := strconv.Atoi(h.Get(ReplyErrorCodeHeader))
+	if err != nil {
+		return nil, false
+	}
+	p := problem.Of(code)
+	if title := h.Get(ReplyErrorHeader); title != "" {
+		p.Append(problem.Title(title))
+	}
+	return p, true
+}
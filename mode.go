@@ -0,0 +1,58 @@
+package apierr
+
+import (
+	"net/http"
+
+	"schneider.vip/problem"
+)
+
+// Mode selects how much of the server errors is exposed to the clients.
+type Mode int
+
+const (
+	// ModeDevelopment writes the problems as produced by the handlers and decorators.
+	ModeDevelopment Mode = iota
+	// ModeProduction redacts the server errors (5xx): title and detail are replaced with
+	// generic texts and only the SafeMembers are kept, so that no internal error text
+	// leaks. The loggers, notifiers and observers still get the original error and title.
+	ModeProduction
+)
+
+// ProductionDetail is the detail of the server errors redacted in ModeProduction.
+var ProductionDetail = "An internal error occurred. Please try again later."
+
+// SafeMembers are the problem members of the server errors kept in ModeProduction.
+var SafeMembers = []string{"type", "status", "instance", TraceIDExtension, UserMessageExtension, DocsQueryExtension}
+
+// SetMode sets the mode of the default registry, see Registry.Mode.
+//
+// Example:
+//
+//	if os.Getenv("ENV") == "production" {
+//		apierr.SetMode(apierr.ModeProduction)
+//	}
+func SetMode(m Mode) {
+	defaultRegistry.Mode = m
+}
+
+// redact replaces p with its redacted version when it is a server error in ModeProduction.
+func (r *Registry) redact(p *problem.Problem) *problem.Problem {
+	status := problemStatus(p)
+	if r.Mode != ModeProduction || status < 500 {
+		return p
+	}
+	data := problemMembers(p)
+	safe := map[string]any{}
+	for _, k := range SafeMembers {
+		if v, ok := data[k]; ok {
+			safe[k] = v
+		}
+	}
+	safe["title"] = http.StatusText(status)
+	safe["detail"] = ProductionDetail
+	redacted := problemFromData(safe)
+	if reason := p.Unwrap(); reason != nil {
+		redacted.Append(problem.WrapSilent(reason))
+	}
+	return redacted
+}
@@ -0,0 +1,71 @@
+package apierr_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/debyten/apierr"
+)
+
+func TestRedactionAfterHooks(t *testing.T) {
+	const internal = "connection refused by db-primary:5432"
+	tests := []struct {
+		name      string
+		mode      apierr.Mode
+		status    apierr.HttpStatus
+		wantTitle string
+	}{
+		{"development", apierr.ModeDevelopment, apierr.ServiceUnavailable, internal},
+		{"production server error", apierr.ModeProduction, apierr.ServiceUnavailable, "Service Unavailable"},
+		{"production client error", apierr.ModeProduction, apierr.Conflict, internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			var notified []apierr.Notification
+			r := apierr.NewRegistry()
+			r.Mode = tt.mode
+			r.Logger = slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			r.LogClientErrors = true
+			r.AddNotifier(apierr.NotifierFunc(func(n apierr.Notification) { notified = append(notified, n) }))
+
+			w := httptest.NewRecorder()
+			r.HandleISE(tt.status.Problemf(internal), w)
+
+			var body map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body["title"] != tt.wantTitle {
+				t.Errorf("replied title = %q, want %q", body["title"], tt.wantTitle)
+			}
+			if tt.status >= 500 {
+				if len(notified) != 1 || notified[0].Title != internal {
+					t.Errorf("notifications = %+v, want one titled %q", notified, internal)
+				}
+			}
+			if !strings.Contains(logs.String(), internal) {
+				t.Errorf("log = %s, want the title %q", logs.String(), internal)
+			}
+		})
+	}
+}
+
+func TestRedactionKeepsSafeMembers(t *testing.T) {
+	r := apierr.NewRegistry()
+	r.Mode = apierr.ModeProduction
+	w := httptest.NewRecorder()
+	r.HandleISE(apierr.InternalServerError.Err(errors.New("secret")).WithExtension("table", "users"), w)
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["detail"] != apierr.ProductionDetail || body["table"] != nil || body["status"] != 500.0 {
+		t.Errorf("body = %v, want redacted detail and no table", body)
+	}
+}
@@ -0,0 +1,73 @@
+// Package mongoerr maps the errors of the MongoDB Go driver (go.mongodb.org/mongo-driver)
+// to problems: missing documents to 404, duplicate keys to 409 and server selection
+// timeouts to 503, which is retryable.
+//
+// The errors are recognized by their methods and messages, hence this package does not
+// depend on the driver. The server messages are not exposed to the clients.
+//
+// Example:
+//
+//	apierr.AddHandler(mongoerr.Handler())
+package mongoerr
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/debyten/apierr"
+	"schneider.vip/problem"
+)
+
+// noDocumentsMessage is the text of mongo.ErrNoDocuments.
+const noDocumentsMessage = "mongo: no documents in result"
+
+// duplicateKeyCodes are the server codes of the duplicate key errors.
+var duplicateKeyCodes = []int{11000, 11001, 12582}
+
+// Handler returns the handler of the package.
+func Handler() apierr.ErrHandler {
+	return func(err error) *problem.Problem {
+		switch {
+		case IsNoDocuments(err):
+			return apierr.NotFound.Problem(http.StatusText(http.StatusNotFound))
+		case IsDuplicateKey(err):
+			return apierr.Conflict.Problem("resource already exists")
+		case IsServerSelection(err):
+			return apierr.ServiceUnavailable.Problem("database unavailable")
+		}
+		return nil
+	}
+}
+
+// IsNoDocuments reports whether err is mongo.ErrNoDocuments.
+func IsNoDocuments(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if err.Error() == noDocumentsMessage {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDuplicateKey reports whether err is a duplicate key write error, like mongo.IsDuplicateKeyError.
+func IsDuplicateKey(err error) bool {
+	var se interface {
+		error
+		HasErrorCode(code int) bool
+	}
+	if errors.As(err, &se) {
+		for _, code := range duplicateKeyCodes {
+			if se.HasErrorCode(code) {
+				return true
+			}
+		}
+	}
+	return err != nil && strings.Contains(err.Error(), "E11000 duplicate key error")
+}
+
+// IsServerSelection reports whether err is a server selection failure
+// (topology.ServerSelectionError), i.e. no server is reachable in time.
+func IsServerSelection(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "server selection error")
+}
@@ -0,0 +1,164 @@
+package apierr
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Notification is the payload sent to the registered Notifier(s) when
+// Handle writes a server error (status >= 500).
+type Notification struct {
+	// ID identifies the occurrence, see Registry.IDGenerator.
+	ID     string
+	Status int
+	Title  string
+	Type   string
+	Err    error
+	Time   time.Time
+	// Stack is the stack trace carried by Err (see StackOf), if any.
+	Stack string
+	// Origin is the package that created Err (see APIErr.Origin), if tracked.
+	Origin string
+	// Values are the request values extracted by the registered context extractors.
+	Values map[string]string
+	// Suppressed is the number of notifications with the same Fingerprint
+	// dropped by Dedup since the previous one was delivered.
+	Suppressed int
+}
+
+// Fingerprint identifies the kind of error carried by the notification:
+// the problem type when present, otherwise status and title.
+func (n Notification) Fingerprint() string {
+	if n.Type != "" {
+		return n.Type
+	}
+	return fmt.Sprintf("%d %s", n.Status, n.Title)
+}
+
+// Notifier receives server errors written by Handle (alerting, webhooks, reporters...).
+type Notifier interface {
+	Notify(n Notification)
+}
+
+// NotifierFunc is an adapter to allow the use of ordinary functions as Notifier.
+type NotifierFunc func(n Notification)
+
+// Notify calls f(n).
+func (f NotifierFunc) Notify(n Notification) {
+	f(n)
+}
+
+// AddNotifier registers n in the default registry, see Registry.AddNotifier.
+func AddNotifier(n Notifier) {
+	defaultRegistry.AddNotifier(n)
+}
+
+// AddNotifier registers n; it will be called for every server error written by Handle.
+func (r *Registry) AddNotifier(n Notifier) {
+	r.notifiers = append(r.notifiers, n)
+}
+
+func (r *Registry) notify(ctx context.Context, err error, data map[string]any) {
+	if len(r.notifiers) == 0 {
+		return
+	}
+	status, _ := data["status"].(float64)
+	if status < 500 {
+		return
+	}
+	n := Notification{ID: r.idGenerator().NewID(), Status: int(status), Err: err, Time: time.Now()}
+	n.Title, _ = data["title"].(string)
+	n.Type, _ = data["type"].(string)
+	n.Stack, _ = r.stackOf(err)
+	n.Origin = OriginOf(err)
+	n.Values = r.contextValues(ctx, nil)
+	r.deliver(ctx, n)
+}
+
+// deliver sends n to the notifiers.
+func (r *Registry) deliver(ctx context.Context, n Notification) {
+	for _, notifier := range r.notifiers {
+		r.runHook(ctx, "notifier", func(ctx context.Context) {
+			if cn, ok := notifier.(ContextNotifier); ok {
+				cn.NotifyContext(ctx, n)
+				return
+			}
+			notifier.Notify(n)
+		})
+	}
+}
+
+// Dedup wraps next so that it is notified at most once per Fingerprint
+// within window. The occurrences dropped in the meantime are reported
+// in the Suppressed field of the next delivered notification.
+//
+// Example:
+//
+//	apierr.AddNotifier(apierr.Dedup(slackNotifier, 10*time.Minute))
+func Dedup(next Notifier, window time.Duration) Notifier {
+	return &dedupNotifier{next: next, window: window, seen: map[string]*dedupEntry{}}
+}
+
+type dedupEntry struct {
+	last       time.Time
+	suppressed int
+}
+
+type dedupNotifier struct {
+	next   Notifier
+	window time.Duration
+	mu     sync.Mutex
+	seen   map[string]*dedupEntry
+}
+
+func (d *dedupNotifier) Notify(n Notification) {
+	if n, ok := d.admit(n); ok {
+		d.next.Notify(n)
+	}
+}
+
+// NotifyContext forwards the context to next when it is a ContextNotifier.
+func (d *dedupNotifier) NotifyContext(ctx context.Context, n Notification) {
+	n, ok := d.admit(n)
+	if !ok {
+		return
+	}
+	if cn, ok := d.next.(ContextNotifier); ok {
+		cn.NotifyContext(ctx, n)
+		return
+	}
+	d.next.Notify(n)
+}
+
+// admit reports whether n must be delivered, setting its Suppressed count.
+func (d *dedupNotifier) admit(n Notification) (Notification, bool) {
+	fp := n.Fingerprint()
+	d.mu.Lock()
+	e, ok := d.seen[fp]
+	if ok && n.Time.Sub(e.last) < d.window {
+		e.suppressed++
+		d.mu.Unlock()
+		return n, false
+	}
+	if !ok {
+		e = &dedupEntry{}
+		d.seen[fp] = e
+	}
+	n.Suppressed = e.suppressed
+	e.last, e.suppressed = n.Time, 0
+	d.prune(n.Time)
+	d.mu.Unlock()
+	return n, true
+}
+
+// prune drops the expired entries without pending suppressed occurrences,
+// so that the map does not grow with every fingerprint ever seen.
+func (d *dedupNotifier) prune(now time.Time) {
+	for fp, e := range d.seen {
+		if e.suppressed == 0 && now.Sub(e.last) >= d.window {
+			delete(d.seen, fp)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package apierr
+
+import (
+	"net/http"
+)
+
+// Observer is notified of every error response written by the registry, whether
+// handled with a problem or not (e.g. the HandleISE fallback), typically to collect metrics.
+// The Outcome carries the status and the problem type (Code) of the response.
+// r is nil when the error is not handled through a request-aware function.
+type Observer interface {
+	OnHandled(o Outcome, err error, r *http.Request)
+}
+
+// ObserverFunc is an adapter to allow the use of ordinary functions as Observer.
+type ObserverFunc func(o Outcome, err error, r *http.Request)
+
+// OnHandled calls f(o, err, r).
+func (f ObserverFunc) OnHandled(o Outcome, err error, r *http.Request) {
+	f(o, err, r)
+}
+
+// AddObserver registers o in the default registry, see Registry.AddObserver.
+func AddObserver(o Observer) {
+	defaultRegistry.AddObserver(o)
+}
+
+// AddObserver registers o. Observers run on the request goroutine, hence they must be fast.
+//
+// Example:
+//
+//	errorsTotal := prometheus.NewCounterVec(prometheus.CounterOpts{
+//		Name: "http_errors_total",
+//	}, []string{"status", "type"})
+//	apierr.AddObserver(apierr.ObserverFunc(func(o apierr.Outcome, _ error, _ *http.Request) {
+//		errorsTotal.WithLabelValues(strconv.Itoa(o.Status), o.Code).Inc()
+//	}))
+func (r *Registry) AddObserver(o Observer) {
+	r.observers = append(r.observers, o)
+}
+
+// finish records the outcome of the handling of err, notifies the observers and emits the event.
+func (r *Registry) finish(w http.ResponseWriter, req *http.Request, err error, o Outcome) {
+	finish(w, o)
+	for _, observer := range r.observers {
+		observer.OnHandled(o, err, req)
+	}
+	r.emit(req, err, o)
+}
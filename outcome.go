@@ -0,0 +1,67 @@
+package apierr
+
+import (
+	"context"
+	"net/http"
+)
+
+// Outcome is the decision taken by Handle/HandleISE for a request.
+type Outcome struct {
+	// Status is the status code written to the client.
+	Status int
+	// Code is the problem type, if any.
+	Code string
+	// Handled is false when the error was unknown and the response
+	// fell back to Internal Server Error.
+	Handled bool
+	// Origin is the package that created the error (see APIErr.Origin), if tracked.
+	Origin string
+	// Values are the request values extracted by the registered extractors, see
+	// RegisterRequestExtractor.
+	Values map[string]string
+}
+
+type outcomeKey struct{}
+
+// TrackOutcome is a middleware that makes the Outcome of Handle/HandleISE
+// available to the middlewares installed after it, which can read it with
+// OutcomeFrom once the next handler returns (access logging, billing...).
+func TrackOutcome(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		o := &Outcome{}
+		ctx := context.WithValue(r.Context(), outcomeKey{}, o)
+		next.ServeHTTP(&outcomeWriter{ResponseWriter: w, outcome: o}, r.WithContext(ctx))
+	})
+}
+
+// OutcomeFrom returns the Outcome tracked by TrackOutcome. The Outcome is the zero
+// value until an error is handled.
+func OutcomeFrom(ctx context.Context) (*Outcome, bool) {
+	o, ok := ctx.Value(outcomeKey{}).(*Outcome)
+	return o, ok
+}
+
+type outcomeWriter struct {
+	http.ResponseWriter
+	outcome *Outcome
+}
+
+// Unwrap is used by http.ResponseController.
+func (w *outcomeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// recordOutcome stores o in the outcomeWriter wrapped by w, if any.
+func recordOutcome(w http.ResponseWriter, o Outcome) {
+	for w != nil {
+		switch rw := w.(type) {
+		case *outcomeWriter:
+			*rw.outcome = o
+			return
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return
+		}
+	}
+}
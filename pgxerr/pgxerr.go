@@ -0,0 +1,92 @@
+// Package pgxerr maps the PostgreSQL errors of pgx (*pgconn.PgError) to problems by
+// SQLSTATE code, with the name of the violated constraint in the ConstraintExtension.
+//
+// The errors are recognized by their SQLState method, hence this package does not
+// depend on pgx. The server messages are not exposed to the clients.
+//
+// Example:
+//
+//	apierr.AddHandler(pgxerr.Handler)
+package pgxerr
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/debyten/apierr"
+	"schneider.vip/problem"
+)
+
+// ConstraintExtension is the problem extension carrying the name of the violated constraint.
+const ConstraintExtension = "constraint"
+
+// SQLSTATE codes mapped by Handler.
+const (
+	UniqueViolation           = "23505"
+	ForeignKeyViolation       = "23503"
+	InvalidTextRepresentation = "22P02"
+	QueryCanceled             = "57014"
+)
+
+// statuses maps the SQLSTATE codes to statuses.
+var statuses = map[string]apierr.HttpStatus{
+	UniqueViolation:           apierr.Conflict,
+	ForeignKeyViolation:       apierr.Conflict,
+	InvalidTextRepresentation: apierr.BadRequest,
+	QueryCanceled:             apierr.ServiceUnavailable,
+}
+
+// Handler maps the PostgreSQL errors by SQLSTATE: unique and foreign key violations
+// to 409, invalid text representations (e.g. a malformed UUID) to 400 and canceled
+// queries (statement timeout) to 503.
+func Handler(err error) *problem.Problem {
+	pgErr, ok := asPgError(err)
+	if !ok {
+		return nil
+	}
+	status, ok := statuses[pgErr.SQLState()]
+	if !ok {
+		return nil
+	}
+	p := status.Problem(http.StatusText(int(status)))
+	if name := ConstraintName(err); name != "" {
+		p.Append(problem.Custom(ConstraintExtension, name))
+	}
+	return p
+}
+
+// SQLState returns the SQLSTATE code of the PostgreSQL error in the chain of err, empty if none.
+func SQLState(err error) string {
+	if pgErr, ok := asPgError(err); ok {
+		return pgErr.SQLState()
+	}
+	return ""
+}
+
+// ConstraintName returns the ConstraintName field of the PostgreSQL error in the chain
+// of err, empty if none.
+func ConstraintName(err error) string {
+	pgErr, ok := asPgError(err)
+	if !ok {
+		return ""
+	}
+	v := reflect.ValueOf(pgErr)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return ""
+	}
+	if f := v.Elem().FieldByName("ConstraintName"); f.IsValid() && f.Kind() == reflect.String {
+		return f.String()
+	}
+	return ""
+}
+
+type pgError interface {
+	error
+	SQLState() string
+}
+
+func asPgError(err error) (pgError, bool) {
+	var pgErr pgError
+	return pgErr, errors.As(err, &pgErr)
+}
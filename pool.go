@@ -0,0 +1,122 @@
+package apierr
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"schneider.vip/problem"
+)
+
+// ErrPoolExhausted is the error, possibly wrapped, returned by bounded worker pools
+// and semaphores that cannot accept more work.
+var ErrPoolExhausted = errors.New("pool exhausted")
+
+// Capacity is implemented by bounded worker pools and semaphores to report their state.
+type Capacity interface {
+	// QueueDepth returns the number of tasks waiting for a worker.
+	QueueDepth() int
+	// DrainRate returns the number of tasks completed per second.
+	DrainRate() float64
+}
+
+// poolSet holds the pools of a Registry. Pools can be registered while errors are handled.
+type poolSet struct {
+	mu    sync.RWMutex
+	pools map[string]Capacity
+}
+
+// RegisterPool registers the Capacity of the pool name in the default registry,
+// see Registry.RegisterPool.
+func RegisterPool(name string, c Capacity) {
+	defaultRegistry.RegisterPool(name, c)
+}
+
+// RegisterPool registers the Capacity of the pool name, used by Backpressure
+// to describe the pool state.
+func (r *Registry) RegisterPool(name string, c Capacity) {
+	if r.pools == nil {
+		// zero Registry: registering a pool is part of its configuration
+		r.pools = &poolSet{}
+	}
+	r.pools.mu.Lock()
+	defer r.pools.mu.Unlock()
+	if r.pools.pools == nil {
+		r.pools.pools = map[string]Capacity{}
+	}
+	r.pools.pools[name] = c
+}
+
+// pool returns the Capacity of the pool name.
+func (r *Registry) pool(name string) (Capacity, bool) {
+	if r.pools == nil {
+		return nil, false
+	}
+	r.pools.mu.RLock()
+	defer r.pools.mu.RUnlock()
+	c, ok := r.pools.pools[name]
+	return c, ok
+}
+
+type poolExhaustedError struct {
+	pool string
+}
+
+func (e *poolExhaustedError) Error() string {
+	return "pool " + e.pool + " exhausted"
+}
+
+func (e *poolExhaustedError) Is(target error) bool {
+	return target == ErrPoolExhausted
+}
+
+// PoolExhausted returns the error for the exhausted pool name. It matches ErrPoolExhausted.
+func PoolExhausted(name string) error {
+	return &poolExhaustedError{pool: name}
+}
+
+// Backpressure converts a pool exhaustion with the pools of the default registry,
+// see Registry.Backpressure.
+//
+// Example:
+//
+//	if err := pool.Submit(task); err != nil {
+//		apierr.HandleISE(apierr.Backpressure(err), w)
+//		return
+//	}
+func Backpressure(err error) error {
+	return defaultRegistry.backpressure(err)
+}
+
+// Backpressure converts a pool exhaustion (an error matching ErrPoolExhausted) to a
+// 503 APIErr. When the error was created by PoolExhausted and the pool is registered,
+// the problem has the "pool" and "queue_depth" extensions and the Retry-After header is
+// the estimated time to drain the queue.
+// Any other error is returned unchanged.
+func (r *Registry) Backpressure(err error) error {
+	return r.backpressure(err)
+}
+
+// backpressure must be called directly by the Backpressure functions, see newAPIErr.
+func (r *Registry) backpressure(err error) error {
+	if !errors.Is(err, ErrPoolExhausted) {
+		return err
+	}
+	e := newAPIErr(2, ServiceUnavailable, err)
+	var pe *poolExhaustedError
+	if !errors.As(err, &pe) {
+		return e
+	}
+	c, ok := r.pool(pe.pool)
+	e.opts = append(e.opts, problem.Custom("pool", pe.pool))
+	if !ok {
+		return e
+	}
+	depth := c.QueueDepth()
+	e.opts = append(e.opts, problem.Custom("queue_depth", depth))
+	if rate := c.DrainRate(); rate > 0 {
+		drain := time.Duration(float64(depth) / rate * float64(time.Second))
+		e.WithHeader("Retry-After", retryAfterSeconds(drain))
+	}
+	return e
+}
@@ -0,0 +1,44 @@
+package apierr
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+
+	"schneider.vip/problem"
+)
+
+// activeHandler returns the problem of h for err, nil for a quarantined handler:
+// it is runHandler outside of the rollout, without logging.
+func activeHandler(h NamedHandler, err error) *problem.Problem {
+	if h.Quarantined {
+		return nil
+	}
+	return h.Handler(err)
+}
+
+// runHandler returns the problem of h for err. The problems of a quarantined handler
+// are logged and, outside of its rollout, discarded.
+func (r *Registry) runHandler(h NamedHandler, err error) *problem.Problem {
+	p := h.Handler(err)
+	if p == nil || !h.Quarantined {
+		return p
+	}
+	applied := rand.Float64()*100 < h.Rollout
+	if r.Logger != nil {
+		data := problemData(p)
+		title, _ := data["title"].(string)
+		r.Logger.LogAttrs(context.Background(), slog.LevelInfo, "quarantined handler matched",
+			slog.String("handler", h.Name),
+			slog.Int("status", problemStatus(p)),
+			slog.String("title", title),
+			slog.Float64("rollout", h.Rollout),
+			slog.Bool("applied", applied),
+			slog.String("error", err.Error()),
+		)
+	}
+	if !applied {
+		return nil
+	}
+	return p
+}
@@ -0,0 +1,80 @@
+package apierr_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/debyten/apierr"
+)
+
+func TestQuarantinedHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		rollout float64
+		want    string
+		applied string
+	}{
+		{"no rollout", 0, "current", `"applied":false`},
+		{"full rollout", 100, "candidate", `"applied":true`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			r := apierr.NewRegistry()
+			r.Logger = slog.New(slog.NewJSONHandler(&logs, nil))
+			err := r.RegisterHandlers([]apierr.NamedHandler{
+				{Name: "current", Handler: titled("current")},
+				{Name: "candidate", Priority: 10, Handler: titled("candidate"), Quarantined: true, Rollout: tt.rollout},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := handledTitle(t, r, errCard); got != tt.want {
+				t.Errorf("title = %q, want %q", got, tt.want)
+			}
+			log := logs.String()
+			if !strings.Contains(log, "quarantined handler matched") || !strings.Contains(log, `"handler":"candidate"`) || !strings.Contains(log, tt.applied) {
+				t.Errorf("log = %s, want the candidate match with %s", log, tt.applied)
+			}
+		})
+	}
+}
+
+func TestQuarantinedHandlerNotMatching(t *testing.T) {
+	var logs bytes.Buffer
+	r := apierr.NewRegistry()
+	r.Logger = slog.New(slog.NewJSONHandler(&logs, nil))
+	err := r.RegisterHandlers([]apierr.NamedHandler{
+		{Name: "candidate", Handler: titled("candidate"), Quarantined: true, Rollout: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handledTitle(t, r, apierr.Conflict.Problem("conflict"))
+	if strings.Contains(logs.String(), "quarantined") {
+		t.Errorf("log = %s, want nothing for a problem not converted by a handler", logs.String())
+	}
+}
+
+func TestMappingsQuarantined(t *testing.T) {
+	r := apierr.NewRegistry()
+	err := r.RegisterHandlers([]apierr.NamedHandler{
+		{Name: "current", Handler: titled("current")},
+		{Name: "candidate", Priority: 10, Handler: titled("candidate"), Quarantined: true, Rollout: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := map[string]int{}
+	for _, m := range r.Mappings(errCard) {
+		rows[m.Handler] = m.Status
+	}
+	if rows["current"] != 402 {
+		t.Errorf("rows = %v, want errCard documented as converted by current", rows)
+	}
+	if _, ok := rows["candidate (quarantined)"]; !ok {
+		t.Errorf("rows = %v, want the candidate marked quarantined", rows)
+	}
+}
@@ -0,0 +1,118 @@
+package apierr
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrRateLimited is the error wrapped by the 429 replied by LimitExceeded.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// LimitExceeded is an http.HandlerFunc replying to a rate limited request with a 429 problem,
+// using the default registry. It is meant to replace the plain text responses of the rate
+// limit middlewares: the legacy X-RateLimit-* headers set by the middleware are converted
+// to the standard RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and Retry-After headers.
+//
+// Example with httprate:
+//
+//	r.Use(httprate.Limit(100, time.Minute, httprate.WithLimitHandler(apierr.LimitExceeded)))
+func LimitExceeded(w http.ResponseWriter, r *http.Request) {
+	defaultRegistry.limitExceeded(w, r)
+}
+
+// LimitExceeded is the Registry version of the package-level LimitExceeded.
+func (r *Registry) LimitExceeded(w http.ResponseWriter, req *http.Request) {
+	r.limitExceeded(w, req)
+}
+
+// limitExceeded must be called directly by the LimitExceeded functions, see newAPIErr.
+func (r *Registry) limitExceeded(w http.ResponseWriter, req *http.Request) {
+	e := newAPIErr(2, TooManyRequests, ErrRateLimited)
+	h := w.Header()
+	for _, name := range []string{"Limit", "Remaining"} {
+		if v := legacyRateLimitHeader(h, name); v != "" {
+			e.WithHeader("RateLimit-"+name, v)
+		}
+	}
+	if reset := legacyRateLimitHeader(h, "Reset"); reset != "" {
+		if delay, ok := resetDelay(reset); ok {
+			e.WithHeader("RateLimit-Reset", strconv.Itoa(delay))
+			e.WithHeader("Retry-After", strconv.Itoa(max(delay, 1)))
+		}
+	}
+	r.HandleRequest(e, w, req)
+}
+
+// RateLimited returns a 429 error for a client that exhausted its quota of limit requests,
+// with remaining requests left, until reset. The RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset (delta seconds) headers of the IETF RateLimit headers draft are set,
+// along with Retry-After.
+//
+// Example:
+//
+//	if !bucket.Allow() {
+//		return apierr.RateLimited(bucket.Limit(), bucket.Remaining(), bucket.Reset())
+//	}
+func RateLimited(limit, remaining int, reset time.Time) *APIErr {
+	delay := int(math.Ceil(max(time.Until(reset).Seconds(), 0)))
+	return newAPIErr(1, TooManyRequests, ErrRateLimited).
+		WithHeader("RateLimit-Limit", strconv.Itoa(limit)).
+		WithHeader("RateLimit-Remaining", strconv.Itoa(max(remaining, 0))).
+		WithHeader("RateLimit-Reset", strconv.Itoa(delay)).
+		WithHeader("Retry-After", strconv.Itoa(max(delay, 1)))
+}
+
+// RateLimitMiddleware returns a middleware replying with LimitExceeded when limit
+// returns an error, for the rate limiters that write their own response otherwise.
+// See Registry.RateLimitMiddleware.
+func RateLimitMiddleware(limit func(w http.ResponseWriter, r *http.Request) error) func(http.Handler) http.Handler {
+	return defaultRegistry.RateLimitMiddleware(limit)
+}
+
+// RateLimitMiddleware returns a middleware replying with LimitExceeded when limit
+// returns an error, for the rate limiters that write their own response otherwise.
+//
+// Example with tollbooth:
+//
+//	mw := apierr.RateLimitMiddleware(func(w http.ResponseWriter, r *http.Request) error {
+//		if err := tollbooth.LimitByRequest(lmt, w, r); err != nil {
+//			return err
+//		}
+//		return nil
+//	})
+func (r *Registry) RateLimitMiddleware(limit func(w http.ResponseWriter, r *http.Request) error) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if err := limit(w, req); err != nil {
+				r.LimitExceeded(w, req)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// legacyRateLimitHeader returns the X-RateLimit-<name> (httprate) or
+// X-Rate-Limit-<name> (tollbooth) header.
+func legacyRateLimitHeader(h http.Header, name string) string {
+	if v := h.Get("X-RateLimit-" + name); v != "" {
+		return v
+	}
+	return h.Get("X-Rate-Limit-" + name)
+}
+
+// resetDelay converts a reset header to seconds from now. Values greater than a day
+// are unix timestamps (httprate), smaller ones are already delays.
+func resetDelay(reset string) (int, bool) {
+	v, err := strconv.ParseInt(reset, 10, 64)
+	if err != nil || v < 0 {
+		return 0, false
+	}
+	if v > 24*60*60 {
+		v = max(v-time.Now().Unix(), 0)
+	}
+	return int(v), true
+}
@@ -0,0 +1,46 @@
+package apierr
+
+import (
+	"net/http"
+
+	"schneider.vip/problem"
+)
+
+// RawResponder writes the response of a problem in place of its serialization, e.g.
+// an exact legacy body. req is nil when the error is not handled through a
+// request-aware function. The headers of the error are already set on w.
+type RawResponder func(w http.ResponseWriter, req *http.Request, p *problem.Problem)
+
+// RegisterRawResponder registers fn for status in the default registry, see Registry.RegisterRawResponder.
+func RegisterRawResponder(status HttpStatus, fn RawResponder) {
+	defaultRegistry.RegisterRawResponder(status, fn)
+}
+
+// RegisterRawResponder makes fn write the responses of the problems of status, bypassing
+// the encoders. The problem still flows through the decorators, the localization and
+// the status overrider before, and through the hooks (logging, notifiers, observers...)
+// after. The status is the outgoing one, after DefaultStatusOverrider.
+//
+// Example:
+//
+//	apierr.RegisterRawResponder(apierr.PaymentRequired, func(w http.ResponseWriter, _ *http.Request, _ *problem.Problem) {
+//		w.Header().Set("Content-Type", "application/xml")
+//		w.WriteHeader(http.StatusPaymentRequired)
+//		io.WriteString(w, legacyPaymentRequiredXML)
+//	})
+func (r *Registry) RegisterRawResponder(status HttpStatus, fn RawResponder) {
+	if r.rawResponders == nil {
+		r.rawResponders = map[int]RawResponder{}
+	}
+	r.rawResponders[int(status)] = fn
+}
+
+// writeRaw writes p with the raw responder of its status, reporting whether there is one.
+func (r *Registry) writeRaw(w http.ResponseWriter, req *http.Request, p *problem.Problem) bool {
+	fn, ok := r.rawResponders[problemStatus(p)]
+	if !ok {
+		return false
+	}
+	fn(w, req, p)
+	return true
+}
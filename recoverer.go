@@ -0,0 +1,99 @@
+package apierr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+
+	"schneider.vip/problem"
+)
+
+// PanicError is the error built from a recovered panic value.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	stack []uintptr
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value when it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// StackTrace returns the program counters of the panicking goroutine, see StackOf.
+func (e *PanicError) StackTrace() []uintptr {
+	return e.stack
+}
+
+// PanicTranslator converts a panic value to a problem, returning nil when the value is not recognized.
+type PanicTranslator func(v any) *problem.Problem
+
+// AddPanicTranslator registers t in the default registry, see Registry.AddPanicTranslator.
+func AddPanicTranslator(t PanicTranslator) {
+	defaultRegistry.AddPanicTranslator(t)
+}
+
+// AddPanicTranslator registers t, used by Recoverer to reply to the panics of domain code
+// with typed sentinels with the right status. The translators are tried in registration order.
+//
+// Example:
+//
+//	apierr.AddPanicTranslator(func(v any) *problem.Problem {
+//		if a, ok := v.(abort); ok {
+//			return apierr.HttpStatus(a.status).Problem(a.reason)
+//		}
+//		return nil
+//	})
+func (r *Registry) AddPanicTranslator(t PanicTranslator) {
+	r.panicTranslators = append(r.panicTranslators, t)
+}
+
+// Recoverer is a middleware recovering the panics of next with the default registry,
+// see Registry.Recoverer.
+func Recoverer(next http.Handler) http.Handler {
+	return defaultRegistry.Recoverer(next)
+}
+
+// Recoverer is a middleware recovering the panics of next: the panic value (error, string
+// or any other value) is converted to a PanicError and replied with the problem returned by the
+// first matching PanicTranslator, or with a 500 problem, after running the decorators.
+// The panic value is not exposed to the client.
+//
+// http.ErrAbortHandler is not recovered, so that the server can abort the response.
+func (r *Registry) Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(v)
+			}
+			r.handle(req.Context(), r.panicProblem(v).Append(problem.WrapSilent(newPanicError(v))), w, req)
+		}()
+		next.ServeHTTP(w, req)
+	})
+}
+
+func newPanicError(v any) *PanicError {
+	pcs := make([]uintptr, 64)
+	// skip runtime.Callers, newPanicError, the deferred function and runtime.gopanic
+	n := runtime.Callers(4, pcs)
+	return &PanicError{Value: v, stack: pcs[:n]}
+}
+
+func (r *Registry) panicProblem(v any) *problem.Problem {
+	for _, t := range r.panicTranslators {
+		if p := t(v); p != nil {
+			return cloneProblem(p)
+		}
+	}
+	return InternalServerError.Problem(http.StatusText(http.StatusInternalServerError))
+}
@@ -0,0 +1,75 @@
+package apierr
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"schneider.vip/problem"
+)
+
+// RedirectError is an error replied with a redirect, e.g. to the login page when
+// the session expired. It is recognized by Handle anywhere in the error chain.
+type RedirectError struct {
+	// Status is the redirect status (302, 303, 307...).
+	Status HttpStatus
+	// Location is the redirect target.
+	Location string
+	// Err is the reason of the redirect, if any.
+	Err error
+}
+
+// Redirect returns a RedirectError to location with status.
+//
+// Example:
+//
+//	if errors.Is(err, auth.ErrSessionExpired) {
+//		return apierr.Redirect(http.StatusSeeOther, "/login", err)
+//	}
+func Redirect(status HttpStatus, location string, err error) *RedirectError {
+	return &RedirectError{Status: status, Location: location, Err: err}
+}
+
+func (e *RedirectError) Error() string {
+	if e.Err == nil {
+		return "redirect to " + e.Location
+	}
+	return "redirect to " + e.Location + ": " + e.Err.Error()
+}
+
+// Unwrap returns the reason of the redirect.
+func (e *RedirectError) Unwrap() error {
+	return e.Err
+}
+
+// Problem converts the redirect to a problem.Problem carrying the "location" extension.
+func (e *RedirectError) Problem() *problem.Problem {
+	return problem.Of(int(e.Status)).Append(problem.Custom("location", e.Location))
+}
+
+// redirect writes the redirect when err is a RedirectError. Clients not accepting
+// HTML (APIs) get the redirect as a problem body too; when req is nil the
+// response has no body.
+func (r *Registry) redirect(err error, w http.ResponseWriter, req *http.Request) bool {
+	var re *RedirectError
+	if !errors.As(err, &re) {
+		return false
+	}
+	w.Header().Set("Location", re.Location)
+	switch {
+	case req == nil:
+		w.WriteHeader(int(re.Status))
+	case acceptsProblem(req):
+		r.writeProblem(w, re.Problem(), r.encoder(req))
+	default:
+		http.Redirect(w, req, re.Location, int(re.Status))
+	}
+	r.finish(w, req, err, Outcome{Status: int(re.Status), Handled: true})
+	return true
+}
+
+// acceptsProblem reports whether the client is not a browser and accepts JSON.
+func acceptsProblem(req *http.Request) bool {
+	accept := req.Header.Get("Accept")
+	return !strings.Contains(accept, "text/html") && strings.Contains(accept, "json")
+}
@@ -0,0 +1,69 @@
+// Package rediserr maps the errors of the Redis clients (github.com/redis/go-redis and
+// compatible) and of the cache layers built on them to problems.
+//
+// The errors are recognized by their methods and messages, hence this package does
+// not depend on the Redis client.
+//
+// Example:
+//
+//	apierr.AddHandler(rediserr.Handler(rediserr.MissNotFound))
+package rediserr
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+
+	"github.com/debyten/apierr"
+	"schneider.vip/problem"
+)
+
+// MissPolicy selects how a cache miss (redis.Nil) reaching the error handling is replied.
+type MissPolicy int
+
+const (
+	// MissNotFound replies cache misses with 404.
+	MissNotFound MissPolicy = iota
+	// MissPassThrough leaves cache misses to the next handlers: use IsMiss to fall back
+	// to the source of truth before returning the error.
+	MissPassThrough
+)
+
+// nilMessage is the text of redis.Nil.
+const nilMessage = "redis: nil"
+
+// Handler returns the handler mapping cache misses according to policy and the
+// connection failures (refused connections, timeouts) to 503, which is retryable.
+func Handler(policy MissPolicy) apierr.ErrHandler {
+	return func(err error) *problem.Problem {
+		switch {
+		case IsMiss(err):
+			if policy == MissNotFound {
+				return apierr.NotFound.Problem(http.StatusText(http.StatusNotFound))
+			}
+			return nil
+		case IsUnavailable(err):
+			return apierr.ServiceUnavailable.Problem("cache unavailable")
+		}
+		return nil
+	}
+}
+
+// IsMiss reports whether err is a cache miss, i.e. redis.Nil.
+func IsMiss(err error) bool {
+	var re interface {
+		error
+		RedisError()
+	}
+	return errors.As(err, &re) && re.Error() == nilMessage
+}
+
+// IsUnavailable reports whether err is a connection failure: a refused connection or a timeout.
+func IsUnavailable(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
@@ -0,0 +1,321 @@
+package apierr
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"schneider.vip/problem"
+)
+
+// ErrHandler converts err to a problem, returning nil when err is not recognized.
+// The registered handlers are tried by Handle when the error chain does not contain
+// a problem.Problem nor an APIErr.
+type ErrHandler func(err error) *problem.Problem
+
+// Registry holds an error handling policy: handlers, decorators and hooks.
+// The package-level functions (Handle, AddHandler...) use the default registry,
+// while services needing a different policy in the same process can create their own.
+//
+// A Registry must be configured before it is used to handle errors. The zero value is
+// an empty Registry ready to use, like the one of NewRegistry but without DefaultLocale.
+type Registry struct {
+	// DBNotFoundHandler overrides DefaultDBNotFoundHandler when not nil.
+	DBNotFoundHandler DBNotFoundHandler
+	// SecurityAuditor overrides DefaultSecurityAuditor when not nil.
+	SecurityAuditor SecurityAuditor
+	// StatusOverrider overrides DefaultStatusOverrider when not nil.
+	StatusOverrider StatusOverrider
+	// RetryAfterPolicy overrides DefaultRetryAfterPolicy when not nil.
+	RetryAfterPolicy RetryAfterPolicy
+	// DeadlineProblem overrides DefaultDeadlineProblem when not nil.
+	DeadlineProblem func() *problem.Problem
+	// DeadlineRetryAfter overrides DefaultDeadlineRetryAfter when not nil.
+	DeadlineRetryAfter DeadlineRetryAfter
+	// LoadSignal, when not nil, returns the current load of the service, from 0 (idle)
+	// to 1 (saturated), used to compute the Retry-After of the requests exceeding their budget.
+	LoadSignal func() float64
+	// HookTimeout bounds the hooks calling external dependencies (notifiers, localizers...),
+	// so that a hung dependency cannot stall the request goroutine. Zero means no timeout.
+	HookTimeout time.Duration
+	// OnHookTimeout, when not nil, is called when a hook exceeds HookTimeout.
+	OnHookTimeout func(hook string, timeout time.Duration)
+	// Localizer localizes the problems in the request-aware functions. When nil, the
+	// translations registered with RegisterTranslations are used.
+	Localizer Localizer
+	// DefaultLocale is the language used when none of the accepted ones is available.
+	DefaultLocale string
+	// Tracer, when not nil, records the handled errors on the span of the request context.
+	Tracer Tracer
+	// Logger, when not nil, logs the server errors with structured attributes
+	// (status, title, type, request method and path, error chain).
+	Logger *slog.Logger
+	// LogClientErrors logs the client errors (4xx) too, at debug level.
+	LogClientErrors bool
+	// StrictExtensions enables the type checking of AttachExtension and, for the
+	// default registry, of APIErr.WithExtension. Enable it in development and tests;
+	// otherwise mismatching values are attached anyway.
+	StrictExtensions bool
+	// ExposeStackTraces writes the stack trace of the server errors (5xx) in the
+	// StackTraceExtension of the problem, see EnableStackTraces. It leaks the internals
+	// of the service: enable it only in development environments.
+	ExposeStackTraces bool
+	// ErrorWriteTimeout is the write deadline set, through http.ResponseController, before
+	// writing an error response, so that slow or stalled clients cannot pin the goroutine
+	// while the error trickles out. The response is flushed right after. Zero means
+	// DefaultErrorWriteTimeout; a negative value disables the deadline.
+	ErrorWriteTimeout time.Duration
+	// RetryLimits bounds the retries directed by the servers in Retry; the fields not
+	// positive are taken from DefaultRetryLimits.
+	RetryLimits RetryLimits
+	// Mode selects how much of the server errors is exposed, see ModeProduction.
+	Mode Mode
+	// Policy selects the problem replied when the error tree contains more than one.
+	Policy ProblemPolicy
+	// FormatVersion is the error format version used when the request does not select
+	// one, see RegisterFormatVersion.
+	FormatVersion string
+	// VersionedType returns the type URI of typeURI for an API version (see WithAPIVersion),
+	// e.g. pointing to the documentation of that version. Type URIs are unchanged when nil.
+	VersionedType func(typeURI, version string) string
+	// DocsQuery adds the DocsQueryExtension to the problems of the registered codes, so
+	// that support tooling can deep-link the users to the relevant documentation.
+	DocsQuery bool
+	// IDGenerator overrides DefaultIDGenerator when not nil.
+	IDGenerator IDGenerator
+	// Instance returns the "instance" member of the problems handled for a request,
+	// when they have none. When nil, the request path is used; see RequestIDInstance.
+	Instance func(req *http.Request) string
+
+	handlers   []NamedHandler
+	decorators []Decorator
+	notifiers  []Notifier
+	observers  []Observer
+	unwrappers []Unwrapper
+	extractors []namedExtractor
+	encoders   []Encoder
+	formats    map[string]map[string]string
+
+	panicTranslators []PanicTranslator
+	translations     map[string]map[string]translation
+	events           *eventBus
+	pools            *poolSet
+	rawResponders    map[int]RawResponder
+	sentinels        []sentinelMapping
+	catalog          codeCatalog
+	problemTypes     []problemType
+}
+
+// NewRegistry returns an empty Registry, with "en" as DefaultLocale.
+func NewRegistry() *Registry {
+	return &Registry{
+		DefaultLocale: "en",
+		unwrappers:    slices.Clone(defaultUnwrappers),
+		encoders:      slices.Clone(defaultEncoders),
+		events:        &eventBus{},
+		pools:         &poolSet{},
+	}
+}
+
+// The unwrappers and encoders of a new Registry, used as well by the zero Registry
+// until it is configured.
+var (
+	defaultUnwrappers = []Unwrapper{errors.Unwrap, CauseUnwrapper}
+	defaultEncoders   = []Encoder{JSONEncoder{}, XMLEncoder{}, TextEncoder{}}
+)
+
+var defaultRegistry = NewRegistry()
+
+// DefaultRegistry returns the registry used by the package-level functions.
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// AddHandler registers h in the default registry, see Registry.AddHandler.
+func AddHandler(h ErrHandler) {
+	defaultRegistry.AddHandler(h)
+}
+
+// AddHandler registers h with priority 0. The handlers are tried by decreasing
+// priority, in registration order for the same priority.
+//
+// Example:
+//
+//	registry.AddHandler(func(err error) *problem.Problem {
+//		if errors.Is(err, sql.ErrNoRows) {
+//			return apierr.NotFound.Problem("record not found")
+//		}
+//		return nil
+//	})
+func (r *Registry) AddHandler(h ErrHandler) {
+	r.insertHandler(NamedHandler{Handler: h})
+}
+
+// Handle is the Registry version of the package-level Handle.
+func (r *Registry) Handle(err error, w http.ResponseWriter) bool {
+	return r.handle(context.Background(), err, w, nil)
+}
+
+// HandleISE is the Registry version of the package-level HandleISE.
+func (r *Registry) HandleISE(err error, w http.ResponseWriter) {
+	r.handleISE(context.Background(), err, w, nil)
+}
+
+// HandleRequest is the Registry version of the package-level HandleRequest.
+func (r *Registry) HandleRequest(err error, w http.ResponseWriter, req *http.Request) bool {
+	return r.handle(req.Context(), err, w, req)
+}
+
+// HandleRequestISE is the Registry version of the package-level HandleRequestISE.
+func (r *Registry) HandleRequestISE(err error, w http.ResponseWriter, req *http.Request) {
+	r.handleISE(req.Context(), err, w, req)
+}
+
+// HandleContext is the Registry version of the package-level HandleContext.
+func (r *Registry) HandleContext(ctx context.Context, err error, w http.ResponseWriter) bool {
+	return r.handle(ctx, err, w, nil)
+}
+
+// HandleContextISE is the Registry version of the package-level HandleContextISE.
+func (r *Registry) HandleContextISE(ctx context.Context, err error, w http.ResponseWriter) {
+	r.handleISE(ctx, err, w, nil)
+}
+
+// handle writes the response for err. req is nil when the error is not handled
+// through a request-aware function.
+func (r *Registry) handle(ctx context.Context, err error, w http.ResponseWriter, req *http.Request) bool {
+	if r.redirect(err, w, req) {
+		return true
+	}
+	ae, h := r.extractProblem(err)
+	// the functions without request nor context (Handle, HandleISE) pass Background
+	if ae == nil && (req != nil || ctx != context.Background()) {
+		var done bool
+		if ae, done = r.contextProblem(ctx, err, w, req); done {
+			return true
+		}
+	}
+	if ae == nil {
+		return false
+	}
+	ctx = r.withValues(ctx, req)
+	ae = r.applyAPIVersion(ctx, ae)
+	ae = r.applyInstance(req, ae)
+	ae = r.applyDocsQuery(err, ae)
+	ae = r.decorate(ctx, err, ae)
+	ae = r.localize(ctx, w, req, err, ae)
+	ae = r.exposeStack(err, ae)
+	ae = r.sanitizeUserMessage(err, ae)
+	ae = withStatus(ae, r.statusOverrider()(problemStatus(ae)))
+	// the hooks see the problem before the redaction, see ModeProduction
+	internal := ae
+	ae = r.redact(ae)
+	ae = r.trace(ctx, w, err, ae)
+	ae = r.applyFormatVersion(w, req, ae)
+	for k, v := range h {
+		w.Header()[k] = v
+	}
+	r.applyDeadlineRetryAfter(ctx, w, err)
+	r.applyRetryAfterPolicy(ctx, w, problemStatus(ae))
+	if !r.writeRaw(w, req, ae) {
+		r.writeProblem(w, ae, r.encoder(req))
+	}
+	r.written(ctx, err, internal, w, req)
+	return true
+}
+
+func (r *Registry) handleISE(ctx context.Context, err error, w http.ResponseWriter, req *http.Request) {
+	if r.handle(ctx, err, w, req) {
+		return
+	}
+	if r.dbNotFoundHandler()(err) {
+		r.recordTrace(ctx, w, err, http.StatusNotFound)
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		r.finish(w, req, err, Outcome{Status: http.StatusNotFound, Handled: true, Values: r.contextValues(ctx, req)})
+		return
+	}
+	r.recordTrace(ctx, w, err, http.StatusInternalServerError)
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	r.log(ctx, req, err, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError), "")
+	r.finish(w, req, err, Outcome{Status: http.StatusInternalServerError, Values: r.contextValues(ctx, req)})
+}
+
+// written runs the hooks interested in a problem written to the client. p is the
+// problem before the redaction of ModeProduction.
+func (r *Registry) written(ctx context.Context, err error, p *problem.Problem, w http.ResponseWriter, req *http.Request) {
+	data := problemData(p)
+	if data[CategoryExtension] == CategorySecurity {
+		r.securityAuditor()(err, p)
+	}
+	r.notify(ctx, err, data)
+	status, _ := data["status"].(float64)
+	code, _ := data["type"].(string)
+	title, _ := data["title"].(string)
+	r.log(ctx, req, err, int(status), title, code)
+	r.logExtensionErrs(ctx, err)
+	r.finish(w, req, err, Outcome{Status: int(status), Code: code, Handled: true, Origin: OriginOf(err), Values: r.contextValues(ctx, req)})
+}
+
+// extractProblem returns the problem found in the tree of err and the headers to
+// write along with it. An APIErr is converted to a problem. When the tree
+// has none, the registered handlers are tried, then the sentinels of Map and
+// finally *http.MaxBytesError (see maxBytesProblem).
+func (r *Registry) extractProblem(err error) (*problem.Problem, http.Header) {
+	if err == nil {
+		return nil, nil
+	}
+	if p, h := r.findProblem(err); p != nil {
+		return p, h
+	}
+	p, _ := r.convertError(err, r.runHandler)
+	return p, nil
+}
+
+// convertError converts err, not carrying a problem, with the first of the handlers
+// (run by run), the sentinels of Map and maxBytesProblem converting it. rule names the
+// one converting err: the handler name, "Map" or "request body".
+func (r *Registry) convertError(err error, run func(NamedHandler, error) *problem.Problem) (p *problem.Problem, rule string) {
+	for _, h := range r.handlers {
+		if p := run(h, err); p != nil {
+			return p, h.Name
+		}
+	}
+	if p := r.mapSentinel(err); p != nil {
+		return p, "Map"
+	}
+	if p := maxBytesProblem(err); p != nil {
+		return p, "request body"
+	}
+	return nil, ""
+}
+
+func (r *Registry) dbNotFoundHandler() DBNotFoundHandler {
+	if r.DBNotFoundHandler != nil {
+		return r.DBNotFoundHandler
+	}
+	return DefaultDBNotFoundHandler
+}
+
+func (r *Registry) securityAuditor() SecurityAuditor {
+	if r.SecurityAuditor != nil {
+		return r.SecurityAuditor
+	}
+	return DefaultSecurityAuditor
+}
+
+func (r *Registry) statusOverrider() StatusOverrider {
+	if r.StatusOverrider != nil {
+		return r.StatusOverrider
+	}
+	return DefaultStatusOverrider
+}
+
+func (r *Registry) retryAfterPolicy() RetryAfterPolicy {
+	if r.RetryAfterPolicy != nil {
+		return r.RetryAfterPolicy
+	}
+	return DefaultRetryAfterPolicy
+}
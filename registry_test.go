@@ -0,0 +1,202 @@
+package apierr_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/debyten/apierr"
+	"schneider.vip/problem"
+)
+
+var errNoRows = errors.New("no rows")
+
+func noRowsHandler(err error) *problem.Problem {
+	if errors.Is(err, errNoRows) {
+		return apierr.NotFound.Problem("record not found")
+	}
+	return nil
+}
+
+func TestRegistryHandle(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(r *apierr.Registry)
+		err     error
+		request bool
+		status  int
+		members map[string]any
+		header  http.Header
+	}{
+		{
+			name:   "problem",
+			err:    apierr.Conflict.Problem("version conflict"),
+			status: 409, members: map[string]any{"title": "version conflict"},
+		},
+		{
+			name:   "APIErr with extras, extensions and headers",
+			err:    apierr.TooManyRequests.Err(errors.New("slow down")).WithExtra("plan", "free").WithExtension("limit", 10).WithHeader("Retry-After", "30"),
+			status: 429, members: map[string]any{"detail": "slow down", "limit": 10.0},
+			header: http.Header{"Retry-After": {"30"}, apierr.AppErrorHeader + "-Plan": {"free"}},
+		},
+		{
+			name:   "handler",
+			setup:  func(r *apierr.Registry) { r.AddHandler(noRowsHandler) },
+			err:    errors.Join(errors.New("load user"), errNoRows),
+			status: 404, members: map[string]any{"title": "record not found"},
+		},
+		{
+			name:   "sentinel",
+			setup:  func(r *apierr.Registry) { r.Map(errNoRows, apierr.Gone, "deleted") },
+			err:    errNoRows,
+			status: 410, members: map[string]any{"title": "deleted"},
+		},
+		{
+			name: "handler before sentinel",
+			setup: func(r *apierr.Registry) {
+				r.Map(errNoRows, apierr.Gone, "deleted")
+				r.AddHandler(noRowsHandler)
+			},
+			err:    errNoRows,
+			status: 404,
+		},
+		{
+			name: "decorators in order",
+			setup: func(r *apierr.Registry) {
+				r.AddDecorator(func(_ context.Context, _ error, p *problem.Problem) { p.Append(problem.Custom("step", "first")) })
+				r.AddDecorator(func(_ context.Context, _ error, p *problem.Problem) { p.Append(problem.Custom("step", "second")) })
+			},
+			err:    apierr.BadRequest.Problem("invalid"),
+			status: 400, members: map[string]any{"step": "second"},
+		},
+		{
+			name:    "instance from the request",
+			err:     apierr.BadRequest.Problem("invalid"),
+			request: true,
+			status:  400, members: map[string]any{"instance": "/orders/42"},
+		},
+		{
+			name:   "no instance without request",
+			err:    apierr.BadRequest.Problem("invalid"),
+			status: 400, members: map[string]any{"instance": nil},
+		},
+		{
+			name:   "status overrider",
+			setup:  func(r *apierr.Registry) { r.StatusOverrider = func(int) int { return 200 } },
+			err:    apierr.BadRequest.Problem("invalid"),
+			status: 200, members: map[string]any{"status": 200.0},
+		},
+		{
+			name:   "unknown error",
+			err:    errors.New("boom"),
+			status: 500,
+		},
+		{
+			name:   "redirect",
+			err:    apierr.Redirect(http.StatusSeeOther, "/login", nil),
+			status: 303,
+			header: http.Header{"Location": {"/login"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := apierr.NewRegistry()
+			if tt.setup != nil {
+				tt.setup(r)
+			}
+			w := httptest.NewRecorder()
+			if tt.request {
+				r.HandleRequestISE(tt.err, w, httptest.NewRequest(http.MethodPost, "/orders/42", nil))
+			} else {
+				r.HandleISE(tt.err, w)
+			}
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			for k, v := range tt.header {
+				if got := w.Header().Values(k); len(got) != len(v) || got[0] != v[0] {
+					t.Errorf("header %s = %q, want %q", k, got, v)
+				}
+			}
+			if len(tt.members) == 0 {
+				return
+			}
+			var body map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body %q: %v", w.Body.String(), err)
+			}
+			for k, want := range tt.members {
+				if body[k] != want {
+					t.Errorf("%s = %v, want %v", k, body[k], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistryHooks(t *testing.T) {
+	var outcomes []apierr.Outcome
+	r := apierr.NewRegistry()
+	r.AddObserver(apierr.ObserverFunc(func(o apierr.Outcome, _ error, _ *http.Request) {
+		outcomes = append(outcomes, o)
+	}))
+	if r.Handle(errors.New("unknown"), httptest.NewRecorder()) {
+		t.Error("unknown error handled")
+	}
+	r.HandleISE(errors.New("unknown"), httptest.NewRecorder())
+	r.HandleISE(apierr.Conflict.Problem("conflict"), httptest.NewRecorder())
+	want := []apierr.Outcome{{Status: 500}, {Status: 409, Handled: true}}
+	if len(outcomes) != len(want) {
+		t.Fatalf("outcomes = %+v, want %+v", outcomes, want)
+	}
+	for i, o := range outcomes {
+		if o.Status != want[i].Status || o.Handled != want[i].Handled {
+			t.Errorf("outcome %d = %+v, want %+v", i, o, want[i])
+		}
+	}
+}
+
+func TestRegistriesIsolated(t *testing.T) {
+	strict, lenient := apierr.NewRegistry(), apierr.NewRegistry()
+	strict.AddHandler(noRowsHandler)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			strict.HandleISE(errNoRows, w)
+			if w.Code != 404 {
+				t.Errorf("strict status = %d, want 404", w.Code)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			lenient.HandleISE(errNoRows, w)
+			if w.Code != 500 {
+				t.Errorf("lenient status = %d, want 500", w.Code)
+			}
+		}()
+	}
+	wg.Wait()
+	if apierr.Handle(errNoRows, httptest.NewRecorder()) {
+		t.Error("handler leaked into the default registry")
+	}
+}
+
+func TestZeroRegistry(t *testing.T) {
+	var r apierr.Registry
+	r.AddHandler(noRowsHandler)
+	_, unsubscribe := r.Subscribe(1)
+	defer unsubscribe()
+	w := httptest.NewRecorder()
+	r.HandleRequestISE(errNoRows, w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != 404 {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,53 @@
+package apierr
+
+import (
+	"fmt"
+	"time"
+
+	"schneider.vip/problem"
+)
+
+// DuplicateExtension is the problem extension describing a duplicate resource, see HttpStatus.Duplicate.
+const DuplicateExtension = "duplicate"
+
+// Deletion extensions, see HttpStatus.DeletedAt.
+const (
+	DeletedAtExtension = "deleted_at"
+	DeletedByExtension = "deleted_by"
+)
+
+// DuplicateResource is the value of the DuplicateExtension.
+type DuplicateResource struct {
+	Resource string `json:"resource"`
+	Field    string `json:"field"`
+	Value    any    `json:"value"`
+}
+
+// Duplicate returns the problem for a resource already existing with the same value of a unique field,
+// meant to be used as Conflict.Duplicate.
+//
+// Example:
+//
+//	return apierr.Conflict.Duplicate("user", "email", email)
+func (h HttpStatus) Duplicate(resource, field string, value any) *problem.Problem {
+	return h.Problemf("%s already exists", resource).Append(
+		problem.Detail(fmt.Sprintf("a %s with %s %v already exists", resource, field, value)),
+		problem.Custom(DuplicateExtension, DuplicateResource{Resource: resource, Field: field, Value: value}),
+	)
+}
+
+// DeletedAt returns the problem for a soft-deleted resource (tombstone), meant to be used as Gone.DeletedAt.
+// by is the actor who deleted the resource; it is omitted when empty.
+//
+// Example:
+//
+//	if order.DeletedAt != nil {
+//		return apierr.Gone.DeletedAt(*order.DeletedAt, order.DeletedBy)
+//	}
+func (h HttpStatus) DeletedAt(t time.Time, by string) *problem.Problem {
+	p := h.Problem("resource deleted").Append(problem.Custom(DeletedAtExtension, t.UTC().Format(time.RFC3339)))
+	if by != "" {
+		p.Append(problem.Custom(DeletedByExtension, by))
+	}
+	return p
+}
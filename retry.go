@@ -0,0 +1,54 @@
+package apierr
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Retryable reports whether the operation that returned err may be retried,
+// based on the status of the problem found in the error chain:
+//
+//   - 408, 425, 429 and 5xx (except 501 and 505) are retryable;
+//   - the remaining 4xx are not, since retrying would produce the same result;
+//   - errors without a problem are considered transient, hence retryable.
+//
+// It allows background workers to reuse the HTTP classification. Example with asynq:
+//
+//	if err != nil && !apierr.Retryable(err) {
+//		return apierr.NonRetryable(err, asynq.SkipRetry)
+//	}
+//
+// and with Temporal:
+//
+//	if err != nil && !apierr.Retryable(err) {
+//		return temporal.NewNonRetryableApplicationError(err.Error(), "apierr", err)
+//	}
+func Retryable(err error) bool {
+	return defaultRegistry.Retryable(err)
+}
+
+// Retryable is the Registry version of the package-level Retryable: the problem of err
+// is found with the handlers and unwrappers of r.
+func (r *Registry) Retryable(err error) bool {
+	p, _ := r.extractProblem(err)
+	if p == nil {
+		return true
+	}
+	return retryableStatus(problemStatus(p))
+}
+
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	case http.StatusNotImplemented, http.StatusHTTPVersionNotSupported:
+		return false
+	}
+	return status >= 500
+}
+
+// NonRetryable wraps err with marker, the sentinel used by a task queue to skip
+// retries (e.g. asynq.SkipRetry). Both errors can be matched with errors.Is.
+func NonRetryable(err, marker error) error {
+	return fmt.Errorf("%w: %w", err, marker)
+}
@@ -0,0 +1,77 @@
+package apierr
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"schneider.vip/problem"
+)
+
+// RetryAfterPolicy computes server-side the Retry-After delay of a 429 or 503 response
+// written for the request context ctx. ok is false to keep the Retry-After set by the error, if any.
+type RetryAfterPolicy func(ctx context.Context, status int) (d time.Duration, ok bool)
+
+// DefaultRetryAfterPolicy is used by Handle for 429 and 503 responses; it overrides the
+// Retry-After set by the error, so that clients cannot game a static value.
+// The default keeps the Retry-After set by the error.
+var DefaultRetryAfterPolicy RetryAfterPolicy = func(_ context.Context, _ int) (time.Duration, bool) {
+	return 0, false
+}
+
+// RetryAfterByKey returns a RetryAfterPolicy computing the delay for the client identified by
+// the context value key (see RegisterContextExtractor).
+//
+// Example:
+//
+//	apierr.RegisterContextExtractor("client", apiKeyFromContext)
+//	apierr.DefaultRetryAfterPolicy = apierr.RetryAfterByKey("client", func(client string, _ int) time.Duration {
+//		return limiter.Penalty(client)
+//	})
+func RetryAfterByKey(key string, fn func(client string, status int) time.Duration) RetryAfterPolicy {
+	return func(ctx context.Context, status int) (time.Duration, bool) {
+		client, ok := ContextValues(ctx)[key]
+		if !ok {
+			return 0, false
+		}
+		return fn(client, status), true
+	}
+}
+
+func (r *Registry) applyRetryAfterPolicy(ctx context.Context, w http.ResponseWriter, status int) {
+	if status != http.StatusTooManyRequests && status != http.StatusServiceUnavailable {
+		return
+	}
+	if d, ok := r.retryAfterPolicy()(ctx, status); ok {
+		w.Header().Set("Retry-After", retryAfterSeconds(d))
+	}
+}
+
+// retryAfterSeconds formats d as delay-seconds, rounded up to at least one second.
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(d.Seconds()))))
+}
+
+// RetryAfter sets the Retry-After header of the error to d, in seconds (at least one).
+func (e *APIErr) RetryAfter(d time.Duration) *APIErr {
+	return e.WithHeader("Retry-After", retryAfterSeconds(d))
+}
+
+// RetryAt sets the Retry-After header of the error to t, as an HTTP-date.
+func (e *APIErr) RetryAt(t time.Time) *APIErr {
+	return e.WithHeader("Retry-After", t.UTC().Format(http.TimeFormat))
+}
+
+// ProblemWithRetry returns an APIErr with the given title and the Retry-After header set to
+// retryAfter, meant to be used with TooManyRequests and ServiceUnavailable.
+//
+// Example:
+//
+//	return apierr.TooManyRequests.ProblemWithRetry("too many exports", time.Minute)
+func (h HttpStatus) ProblemWithRetry(title string, retryAfter time.Duration) *APIErr {
+	e := newAPIErr(1, h, nil)
+	e.opts = append(e.opts, problem.Title(title))
+	return e.RetryAfter(retryAfter)
+}
@@ -0,0 +1,167 @@
+package apierr
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"schneider.vip/problem"
+)
+
+// RetryPolicyExtension is the problem extension carrying the RetryPolicy of a retryable error.
+const RetryPolicyExtension = "retry_policy"
+
+// RetryPolicy tells the clients how to retry a failed request: at most MaxAttempts
+// attempts (the first one included), waiting an exponential backoff between them.
+type RetryPolicy struct {
+	MaxAttempts int `json:"max_attempts"`
+	// InitialBackoffMs is the wait before the second attempt, in milliseconds.
+	InitialBackoffMs int64 `json:"initial_backoff_ms"`
+	// MaxBackoffMs caps the wait between two attempts, in milliseconds. Zero means no cap.
+	MaxBackoffMs int64 `json:"max_backoff_ms,omitempty"`
+	// Multiplier grows the backoff after each attempt; values below 1 are treated as 1.
+	Multiplier float64 `json:"multiplier,omitempty"`
+	// Jitter is the fraction (0-1) of the backoff randomized, to spread the retries of the clients.
+	Jitter float64 `json:"jitter,omitempty"`
+}
+
+// Option returns the problem.Option setting the RetryPolicyExtension to rp.
+func (rp RetryPolicy) Option() problem.Option {
+	return problem.Custom(RetryPolicyExtension, rp)
+}
+
+// WithRetryPolicy sets the RetryPolicyExtension of the problem of e to rp.
+//
+// Example:
+//
+//	return apierr.ServiceUnavailable.Err(err).WithRetryPolicy(apierr.RetryPolicy{
+//		MaxAttempts: 5, InitialBackoffMs: 200, MaxBackoffMs: 5000, Multiplier: 2, Jitter: 0.2,
+//	})
+func (e *APIErr) WithRetryPolicy(rp RetryPolicy) *APIErr {
+	e.opts = append(e.opts, rp.Option())
+	return e
+}
+
+// RetryLimits bounds the retries directed by the servers in Retry, so that a
+// misconfigured or hostile RetryPolicy cannot make the client retry in a tight loop
+// or for ever.
+type RetryLimits struct {
+	// MaxAttempts caps the MaxAttempts of the policies.
+	MaxAttempts int
+	// MinBackoff is the minimum wait between two attempts.
+	MinBackoff time.Duration
+	// MaxBackoff caps the wait between two attempts.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryLimits are the limits used when a RetryLimits field is not positive.
+var DefaultRetryLimits = RetryLimits{MaxAttempts: 5, MinBackoff: 100 * time.Millisecond, MaxBackoff: 30 * time.Second}
+
+// maxDurationMs is the longest time.Duration, in milliseconds.
+const maxDurationMs = float64(math.MaxInt64 / int64(time.Millisecond))
+
+// Backoff returns the wait before the attempt following the failed attempt number
+// attempt (starting from 1), jitter included. It is capped by the longest time.Duration.
+func (rp RetryPolicy) Backoff(attempt int) time.Duration {
+	if rp.InitialBackoffMs <= 0 {
+		return 0
+	}
+	d := float64(rp.InitialBackoffMs) * math.Pow(max(rp.Multiplier, 1), float64(max(attempt-1, 0)))
+	if rp.MaxBackoffMs > 0 {
+		d = min(d, float64(rp.MaxBackoffMs))
+	}
+	if j := min(max(rp.Jitter, 0), 1); j > 0 {
+		d *= 1 - j + 2*j*rand.Float64()
+	}
+	if !(d < maxDurationMs) {
+		// +Inf when the multiplier overflows, without MaxBackoffMs
+		d = maxDurationMs
+	}
+	return time.Duration(d * float64(time.Millisecond))
+}
+
+// RetryPolicyOf returns the RetryPolicy of the problem found in the chain of err,
+// e.g. an error converted by FromResponse.
+func RetryPolicyOf(err error) (RetryPolicy, bool) {
+	return defaultRegistry.RetryPolicyOf(err)
+}
+
+// RetryPolicyOf is the Registry version of the package-level RetryPolicyOf.
+func (r *Registry) RetryPolicyOf(err error) (RetryPolicy, bool) {
+	p, _ := r.extractProblem(err)
+	if p == nil {
+		return RetryPolicy{}, false
+	}
+	raw, ok := problemData(p)[RetryPolicyExtension]
+	if !ok {
+		return RetryPolicy{}, false
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return RetryPolicy{}, false
+	}
+	var rp RetryPolicy
+	if json.Unmarshal(b, &rp) != nil {
+		return RetryPolicy{}, false
+	}
+	return rp, true
+}
+
+// Retry calls fn until it succeeds, as directed by the server: fn is retried only while
+// its error is Retryable and carries a RetryPolicy, within the attempts of the policy
+// and waiting its backoff, both bounded by DefaultRetryLimits. It returns the last error of fn, or the error of ctx when
+// it is done while waiting.
+//
+// Example:
+//
+//	err := apierr.Retry(ctx, func(ctx context.Context) error {
+//		resp, err := client.Do(req.WithContext(ctx))
+//		if err != nil {
+//			return err
+//		}
+//		defer resp.Body.Close()
+//		return apierr.FromResponse(resp)
+//	})
+func Retry(ctx context.Context, fn func(ctx context.Context) error) error {
+	return defaultRegistry.Retry(ctx, fn)
+}
+
+// Retry is the Registry version of the package-level Retry, see Registry.Retryable.
+// The policies are bounded by Registry.RetryLimits.
+func (r *Registry) Retry(ctx context.Context, fn func(ctx context.Context) error) error {
+	limits := r.retryLimits()
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || !r.Retryable(err) {
+			return err
+		}
+		rp, ok := r.RetryPolicyOf(err)
+		if !ok || attempt >= min(rp.MaxAttempts, limits.MaxAttempts) {
+			return err
+		}
+		t := time.NewTimer(min(max(rp.Backoff(attempt), limits.MinBackoff), limits.MaxBackoff))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// retryLimits returns the RetryLimits of r, completed with DefaultRetryLimits.
+func (r *Registry) retryLimits() RetryLimits {
+	l := r.RetryLimits
+	if l.MaxAttempts <= 0 {
+		l.MaxAttempts = DefaultRetryLimits.MaxAttempts
+	}
+	if l.MinBackoff <= 0 {
+		l.MinBackoff = DefaultRetryLimits.MinBackoff
+	}
+	if l.MaxBackoff <= 0 {
+		l.MaxBackoff = DefaultRetryLimits.MaxBackoff
+	}
+	return l
+}
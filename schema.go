@@ -0,0 +1,171 @@
+package apierr
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// problemType is the shape of the problems identified by a type URI.
+type problemType struct {
+	uri        string
+	extensions reflect.Type
+}
+
+// RegisterType registers the shape of the problems of type typeURI in the default
+// registry, see Registry.RegisterType.
+func RegisterType(typeURI string, extensions any) {
+	defaultRegistry.RegisterType(typeURI, extensions)
+}
+
+// RegisterType registers the shape of the problems of type typeURI. extensions is a struct
+// (or a pointer to struct) whose exported fields describe the problem extensions;
+// members are named after the json tag and the ones without omitempty are required.
+// extensions can be nil when the problem has no extensions.
+//
+// The registered shapes are served as JSON Schema by SchemaHandler.
+//
+// Example:
+//
+//	type quotaExceeded struct {
+//		Limit int       `json:"limit"`
+//		Reset time.Time `json:"reset"`
+//	}
+//
+//	apierr.RegisterType("https://api.example.com/errors/quota-exceeded", quotaExceeded{})
+func (r *Registry) RegisterType(typeURI string, extensions any) {
+	t := reflect.TypeOf(extensions)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	r.problemTypes = append(r.problemTypes, problemType{uri: typeURI, extensions: t})
+}
+
+func (r *Registry) lookupType(typeURI string) (problemType, bool) {
+	for i := len(r.problemTypes) - 1; i >= 0; i-- {
+		if r.problemTypes[i].uri == typeURI {
+			return r.problemTypes[i], true
+		}
+	}
+	return problemType{}, false
+}
+
+// Schema returns the JSON Schema of the problems of type typeURI registered in the
+// default registry, see Registry.Schema.
+func Schema(typeURI string) (map[string]any, bool) {
+	return defaultRegistry.Schema(typeURI)
+}
+
+// Schema returns the JSON Schema of the problems of type typeURI registered with RegisterType.
+func (r *Registry) Schema(typeURI string) (map[string]any, bool) {
+	pt, ok := r.lookupType(typeURI)
+	if !ok {
+		return nil, false
+	}
+	properties := map[string]any{
+		"type":     map[string]any{"type": "string", "format": "uri-reference", "const": typeURI},
+		"title":    map[string]any{"type": "string"},
+		"status":   map[string]any{"type": "integer", "minimum": 100, "maximum": 599},
+		"detail":   map[string]any{"type": "string"},
+		"instance": map[string]any{"type": "string", "format": "uri-reference"},
+	}
+	var required []string
+	if pt.extensions != nil && pt.extensions.Kind() == reflect.Struct {
+		ext := structSchema(pt.extensions)
+		for k, v := range ext["properties"].(map[string]any) {
+			properties[k] = v
+		}
+		required, _ = ext["required"].([]string)
+	}
+	s := map[string]any{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"$id":        typeURI,
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s, true
+}
+
+// SchemaHandler serves the JSON Schema of the problem types of the default registry,
+// see Registry.SchemaHandler.
+//
+// Example:
+//
+//	mux.Handle("/errors/", apierr.SchemaHandler())
+func SchemaHandler() http.Handler {
+	return defaultRegistry.SchemaHandler()
+}
+
+// SchemaHandler serves the JSON Schema of the problem types registered in r under the
+// path of their type URI.
+func (r *Registry) SchemaHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for i := len(r.problemTypes) - 1; i >= 0; i-- {
+			u, err := url.Parse(r.problemTypes[i].uri)
+			if err != nil || u.Path != req.URL.Path {
+				continue
+			}
+			s, _ := r.Schema(r.problemTypes[i].uri)
+			w.Header().Set("Content-Type", "application/schema+json")
+			_ = json.NewEncoder(w).Encode(s)
+			return
+		}
+		r.HandleRequest(NotFound.Problemf("unknown problem type %s", req.URL.Path), w, req)
+	})
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func typeSchema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	}
+	return map[string]any{}
+}
+
+func structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := jsonName(f)
+		if !ok {
+			continue
+		}
+		properties[name] = typeSchema(f.Type)
+		if !strings.Contains(f.Tag.Get("json"), ",omitempty") {
+			required = append(required, name)
+		}
+	}
+	s := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
@@ -0,0 +1,104 @@
+// Package searcherr maps the error responses of Elasticsearch and OpenSearch to
+// apierr errors, so that search-heavy services translate the backend failures once.
+//
+// It works on the status and the body of the responses, hence with any client
+// (go-elasticsearch, opensearch-go, plain net/http).
+//
+// Example:
+//
+//	res, err := es.Search(es.Search.WithIndex("products"), es.Search.WithBody(query))
+//	if err != nil {
+//		return err
+//	}
+//	defer res.Body.Close()
+//	if err := searcherr.FromResponse(res.StatusCode, res.Body); err != nil {
+//		return err
+//	}
+package searcherr
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/debyten/apierr"
+)
+
+// maxErrorBody is the maximum size of the error bodies read by FromResponse.
+const maxErrorBody = 1 << 20
+
+// Policy maps the backend failures to statuses.
+type Policy struct {
+	// IndexNotFound is the status of index_not_found_exception: NotFound when the index
+	// is chosen by the client, InternalServerError when a missing index is a misconfiguration.
+	IndexNotFound apierr.HttpStatus
+	// Rejected is the status of the rejected executions (full thread pool queues) and of
+	// the 429 replied by the backend: TooManyRequests or ServiceUnavailable.
+	Rejected apierr.HttpStatus
+	// RetryAfter is the Retry-After of the rejected executions; zero omits the header.
+	RetryAfter time.Duration
+}
+
+// DefaultPolicy is the policy used by FromResponse.
+var DefaultPolicy = Policy{
+	IndexNotFound: apierr.NotFound,
+	Rejected:      apierr.TooManyRequests,
+	RetryAfter:    time.Second,
+}
+
+// Error is an error reply of the backend.
+type Error struct {
+	// Status is the status replied by the backend.
+	Status int
+	// Type is the error type, e.g. "index_not_found_exception".
+	Type string
+	// Reason is the error description. It may name indices and documents, hence it
+	// is not part of the error text.
+	Reason string
+}
+
+func (e *Error) Error() string {
+	if e.Type == "" {
+		return "search backend: " + http.StatusText(e.Status)
+	}
+	return "search backend: " + e.Type
+}
+
+// FromResponse converts an error response of the backend with DefaultPolicy, see Policy.FromResponse.
+func FromResponse(status int, body io.Reader) error {
+	return DefaultPolicy.FromResponse(status, body)
+}
+
+// FromResponse converts an error response of the backend to an *apierr.APIErr wrapping
+// an *Error. It returns nil when status is not an error (< 400). The body is consumed.
+//
+// Backend failures not covered by the policy are replied as 502, since the request
+// of the client was not at fault.
+func (p Policy) FromResponse(status int, body io.Reader) error {
+	if status < 400 {
+		return nil
+	}
+	e := &Error{Status: status}
+	var reply struct {
+		Error struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	}
+	if json.NewDecoder(io.LimitReader(body, maxErrorBody)).Decode(&reply) == nil {
+		e.Type, e.Reason = reply.Error.Type, reply.Error.Reason
+	}
+	switch {
+	case e.Type == "index_not_found_exception":
+		return apierr.New(p.IndexNotFound, e)
+	case e.Type == "es_rejected_execution_exception", e.Type == "rejected_execution_exception",
+		status == http.StatusTooManyRequests:
+		ae := apierr.New(p.Rejected, e)
+		if p.RetryAfter > 0 {
+			ae.RetryAfter(p.RetryAfter)
+		}
+		return ae
+	}
+	return apierr.New(apierr.BadGateway, e)
+}
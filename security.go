@@ -0,0 +1,40 @@
+package apierr
+
+import (
+	"schneider.vip/problem"
+)
+
+// CategoryExtension is the problem extension used to classify an error.
+const CategoryExtension = "category"
+
+// CategorySecurity marks problems produced by security checks (CSRF, Origin/Referer...).
+// Handle forwards these problems to DefaultSecurityAuditor.
+const CategorySecurity = "security"
+
+// SecurityAuditor is the audit hook invoked by Handle when a problem
+// of category CategorySecurity is written to the client.
+type SecurityAuditor func(err error, p *problem.Problem)
+
+// DefaultSecurityAuditor override this function to send security failures
+// to the audit log.
+var DefaultSecurityAuditor SecurityAuditor = func(_ error, _ *problem.Problem) {}
+
+// CSRFFailure returns a 403 problem for a missing or invalid CSRF token.
+// The reason is exposed as the problem detail (e.g. "token missing", "token expired").
+func CSRFFailure(reason string) *problem.Problem {
+	return securityProblem("csrf", "CSRF token validation failed", reason)
+}
+
+// OriginFailure returns a 403 problem for a request whose Origin (or Referer)
+// header does not match the allowed origins.
+func OriginFailure(origin string) *problem.Problem {
+	return securityProblem("origin", "origin validation failed", "origin not allowed: "+origin)
+}
+
+func securityProblem(check, title, detail string) *problem.Problem {
+	return Forbidden.Problem(title).Append(
+		problem.Detail(detail),
+		problem.Custom(CategoryExtension, CategorySecurity),
+		problem.Custom("check", check),
+	)
+}
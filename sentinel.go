@@ -0,0 +1,59 @@
+package apierr
+
+import (
+	"errors"
+
+	"schneider.vip/problem"
+)
+
+// sentinelMapping is a row of the table of Map.
+type sentinelMapping struct {
+	sentinel error
+	status   HttpStatus
+	title    string
+}
+
+// Map maps sentinel in the default registry, see Registry.Map.
+func Map(sentinel error, status HttpStatus, title string) {
+	defaultRegistry.Map(sentinel, status, title)
+}
+
+// Map replies the errors matching sentinel (errors.Is) with a problem of status and
+// title, replacing a previous mapping of sentinel. The table is checked after the
+// registered handlers, in mapping order.
+//
+// Example:
+//
+//	apierr.Map(sql.ErrNoRows, apierr.NotFound, "record not found")
+//	apierr.Map(os.ErrNotExist, apierr.NotFound, "file not found")
+//	apierr.Map(io.ErrUnexpectedEOF, apierr.BadRequest, "truncated request body")
+func (r *Registry) Map(sentinel error, status HttpStatus, title string) {
+	m := sentinelMapping{sentinel: sentinel, status: status, title: title}
+	for i, s := range r.sentinels {
+		if s.sentinel == sentinel {
+			r.sentinels[i] = m
+			return
+		}
+	}
+	r.sentinels = append(r.sentinels, m)
+}
+
+// mapSentinel returns the problem of the first sentinel matching err, nil if none matches.
+func (r *Registry) mapSentinel(err error) *problem.Problem {
+	for _, s := range r.sentinels {
+		if errors.Is(err, s.sentinel) {
+			// not HttpStatus.Problem, which would format a '%' of the title
+			return problem.Of(int(s.status)).Append(problem.Title(s.title))
+		}
+	}
+	return nil
+}
+
+// sentinelErrors returns the sentinels of the table of Map.
+func (r *Registry) sentinelErrors() []error {
+	errs := make([]error, len(r.sentinels))
+	for i, s := range r.sentinels {
+		errs[i] = s.sentinel
+	}
+	return errs
+}
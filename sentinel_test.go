@@ -0,0 +1,33 @@
+package apierr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/debyten/apierr"
+)
+
+func TestMapTitleVerbatim(t *testing.T) {
+	errQuota := errors.New("quota exhausted")
+	r := apierr.NewRegistry()
+	r.Map(errQuota, apierr.TooManyRequests, "100% of the quota used")
+	w := httptest.NewRecorder()
+	if !r.Handle(errQuota, w) {
+		t.Fatal("error not handled")
+	}
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", w.Code)
+	}
+	var body struct {
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Title != "100% of the quota used" {
+		t.Errorf("title = %q, want %q", body.Title, "100% of the quota used")
+	}
+}
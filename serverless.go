@@ -0,0 +1,28 @@
+package apierr
+
+import (
+	"net/http"
+)
+
+// Function adapts h to the signature of the serverless HTTP functions, using the
+// default registry. See Registry.Function.
+func Function(h HandlerFunc) http.HandlerFunc {
+	return defaultRegistry.Function(h)
+}
+
+// Function adapts h to the signature of the serverless HTTP functions (Google Cloud
+// Functions, Cloud Run functions, Knative...): the returned error and the panics are
+// replied through the registry, so decorators, sanitizers and encoders behave as in
+// long-running servers.
+//
+// The registry must be configured in an init function, since the platform may
+// invoke the function as soon as the instance starts.
+//
+// Example:
+//
+//	func init() {
+//		functions.HTTP("GetUser", apierr.Function(getUser))
+//	}
+func (r *Registry) Function(h HandlerFunc) http.HandlerFunc {
+	return r.Recoverer(r.Wrap(h)).ServeHTTP
+}
@@ -0,0 +1,96 @@
+package apierr
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"sync/atomic"
+)
+
+// Snapshot returns a copy of the configuration of r: the changes made to r afterwards
+// do not affect the copy, which can be used to handle errors while r is reconfigured.
+// The copy shares the subscribers (see Subscribe) and the pools (see RegisterPool) of r.
+//
+// The hooks (notifiers, observers, localizers...) are shared as well, hence they must
+// be safe for concurrent use, as they are in a single Registry.
+func (r *Registry) Snapshot() *Registry {
+	s := *r
+	s.handlers = slices.Clone(r.handlers)
+	s.decorators = slices.Clone(r.decorators)
+	s.notifiers = slices.Clone(r.notifiers)
+	s.observers = slices.Clone(r.observers)
+	s.unwrappers = slices.Clone(r.unwrappers)
+	s.extractors = slices.Clone(r.extractors)
+	s.encoders = slices.Clone(r.encoders)
+	s.formats = maps.Clone(r.formats)
+	s.panicTranslators = slices.Clone(r.panicTranslators)
+	s.rawResponders = maps.Clone(r.rawResponders)
+	s.sentinels = slices.Clone(r.sentinels)
+	s.catalog.codes = maps.Clone(r.catalog.codes)
+	s.catalog.ids = slices.Clone(r.catalog.ids)
+	s.catalog.conflicts = slices.Clone(r.catalog.conflicts)
+	s.problemTypes = slices.Clone(r.problemTypes)
+	if r.translations != nil {
+		s.translations = make(map[string]map[string]translation, len(r.translations))
+		for lang, ts := range r.translations {
+			s.translations[lang] = maps.Clone(ts)
+		}
+	}
+	return &s
+}
+
+// AtomicRegistry holds the Registry handling the errors, replaced atomically when the
+// policy is reconfigured at runtime (hot reload, admin toggles...). Handling an error
+// takes no lock: the requests in flight keep the snapshot they started with.
+//
+// Example:
+//
+//	live := apierr.NewAtomicRegistry(registry)
+//	mux.Handle("/", live.Wrap(handler))
+//
+//	// on reload
+//	next := live.Load().Snapshot()
+//	next.Mode = apierr.ModeProduction
+//	live.Store(next)
+type AtomicRegistry struct {
+	current atomic.Pointer[Registry]
+}
+
+// NewAtomicRegistry returns an AtomicRegistry holding a snapshot of r.
+func NewAtomicRegistry(r *Registry) *AtomicRegistry {
+	a := &AtomicRegistry{}
+	a.Store(r)
+	return a
+}
+
+// Load returns the current registry. It must not be modified: reconfigure a Snapshot
+// of it and Store that instead.
+func (a *AtomicRegistry) Load() *Registry {
+	return a.current.Load()
+}
+
+// Store replaces the current registry with a snapshot of r, so that r can no longer
+// affect the errors being handled.
+func (a *AtomicRegistry) Store(r *Registry) {
+	a.current.Store(r.Snapshot())
+}
+
+// HandleRequest handles err with the current registry, see Registry.HandleRequest.
+func (a *AtomicRegistry) HandleRequest(err error, w http.ResponseWriter, req *http.Request) bool {
+	return a.Load().HandleRequest(err, w, req)
+}
+
+// HandleRequestISE handles err with the current registry, see Registry.HandleRequestISE.
+func (a *AtomicRegistry) HandleRequestISE(err error, w http.ResponseWriter, req *http.Request) {
+	a.Load().HandleRequestISE(err, w, req)
+}
+
+// Wrap adapts h to http.Handler, handling its error with the registry current when
+// the request is served. See Registry.Wrap.
+func (a *AtomicRegistry) Wrap(h HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := h(w, req); err != nil {
+			a.HandleRequestISE(err, w, req)
+		}
+	})
+}
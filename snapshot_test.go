@@ -0,0 +1,86 @@
+package apierr_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/debyten/apierr"
+	"schneider.vip/problem"
+)
+
+func TestSnapshotIsolation(t *testing.T) {
+	errOther := errors.New("other")
+	tests := []struct {
+		name   string
+		change func(r *apierr.Registry)
+		err    error
+		want   int
+	}{
+		{"handler", func(r *apierr.Registry) { r.AddHandler(noRowsHandler) }, errNoRows, 500},
+		{"sentinel", func(r *apierr.Registry) { r.Map(errOther, apierr.Gone, "gone") }, errOther, 500},
+		{"status overrider", func(r *apierr.Registry) { r.StatusOverrider = func(int) int { return 200 } }, apierr.Conflict.Problem("conflict"), 409},
+		{"decorator", func(r *apierr.Registry) {
+			r.AddDecorator(func(_ context.Context, _ error, p *problem.Problem) { p.Append(problem.Status(418)) })
+		}, apierr.Conflict.Problem("conflict"), 409},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := apierr.NewRegistry()
+			snap := r.Snapshot()
+			tt.change(r)
+			w := httptest.NewRecorder()
+			snap.HandleISE(tt.err, w)
+			if w.Code != tt.want {
+				t.Errorf("snapshot status = %d, want %d", w.Code, tt.want)
+			}
+			w = httptest.NewRecorder()
+			r.HandleISE(tt.err, w)
+			if w.Code == tt.want {
+				t.Errorf("registry status = %d, want the change applied", w.Code)
+			}
+		})
+	}
+}
+
+func TestSnapshotTranslations(t *testing.T) {
+	r := apierr.NewRegistry()
+	if err := r.RegisterTranslations("it", map[string]apierr.Message{"user_not_found": {Title: "Utente non trovato"}}); err != nil {
+		t.Fatal(err)
+	}
+	snap := r.Snapshot()
+	if err := r.RegisterTranslations("it", map[string]apierr.Message{"user_not_found": {Title: "Nessun utente"}}); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "it")
+	err := apierr.NotFound.Err(errors.New("no user")).WithCode("user_not_found")
+	if got := handledTitleRequest(t, snap, err, req); got != "Utente non trovato" {
+		t.Errorf("snapshot title = %q, want %q", got, "Utente non trovato")
+	}
+	if got := handledTitleRequest(t, r, err, req); got != "Nessun utente" {
+		t.Errorf("registry title = %q, want %q", got, "Nessun utente")
+	}
+}
+
+func TestAtomicRegistry(t *testing.T) {
+	r := apierr.NewRegistry()
+	live := apierr.NewAtomicRegistry(r)
+	// the registry stored is a snapshot, not affected by r
+	r.AddHandler(noRowsHandler)
+	h := live.Wrap(func(http.ResponseWriter, *http.Request) error { return errNoRows })
+	serve := func() int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Code
+	}
+	if got := serve(); got != 500 {
+		t.Errorf("status = %d, want 500 before Store", got)
+	}
+	live.Store(r)
+	if got := serve(); got != 404 {
+		t.Errorf("status = %d, want 404 after Store", got)
+	}
+}
@@ -0,0 +1,162 @@
+package apierr
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+
+	"schneider.vip/problem"
+)
+
+// SOAPVersion selects the envelope produced by EncodeSOAPFault.
+type SOAPVersion int
+
+const (
+	SOAP11 SOAPVersion = iota + 1
+	SOAP12
+)
+
+const (
+	soap11Namespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12Namespace = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// ContentType returns the media type of the SOAP messages of version v.
+func (v SOAPVersion) ContentType() string {
+	if v == SOAP12 {
+		return "application/soap+xml; charset=utf-8"
+	}
+	return "text/xml; charset=utf-8"
+}
+
+type soapEnvelope struct {
+	XMLName xml.Name `xml:"soap:Envelope"`
+	NS      string   `xml:"xmlns:soap,attr"`
+	Body    struct {
+		Fault any `xml:"soap:Fault"`
+	} `xml:"soap:Body"`
+}
+
+type soapDetailEntry struct {
+	XMLName xml.Name
+	Name    string `xml:"name,attr,omitempty"`
+	Value   string `xml:",chardata"`
+}
+
+type soap11Fault struct {
+	Code   string            `xml:"faultcode"`
+	String string            `xml:"faultstring"`
+	Detail []soapDetailEntry `xml:"detail>entry,omitempty"`
+}
+
+type soap12Fault struct {
+	Code   string            `xml:"soap:Code>soap:Value"`
+	Reason soap12Reason      `xml:"soap:Reason>soap:Text"`
+	Detail []soapDetailEntry `xml:"soap:Detail>entry,omitempty"`
+}
+
+type soap12Reason struct {
+	Lang string `xml:"xml:lang,attr"`
+	Text string `xml:",chardata"`
+}
+
+// EncodeSOAPFault writes p to out as a SOAP Fault envelope of version v:
+//
+//   - the fault code is Client/Sender for 4xx problems, Server/Receiver otherwise;
+//   - the fault string is the problem title;
+//   - the fault detail contains the problem detail and extensions, one element per member,
+//     named after it; the members whose name is not a valid XML name are written as
+//     <entry name="...">.
+func EncodeSOAPFault(out io.Writer, p *problem.Problem, v SOAPVersion) error {
+	data := problemData(p)
+	status, _ := data["status"].(float64)
+	title, _ := data["title"].(string)
+	client := status >= 400 && status < 500
+	detail := soapDetail(data)
+
+	env := soapEnvelope{}
+	switch v {
+	case SOAP12:
+		env.NS = soap12Namespace
+		code := "soap:Receiver"
+		if client {
+			code = "soap:Sender"
+		}
+		env.Body.Fault = soap12Fault{Code: code, Reason: soap12Reason{Lang: "en", Text: title}, Detail: detail}
+	default:
+		env.NS = soap11Namespace
+		code := "soap:Server"
+		if client {
+			code = "soap:Client"
+		}
+		env.Body.Fault = soap11Fault{Code: code, String: title, Detail: detail}
+	}
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(out).Encode(env)
+}
+
+// WriteSOAPFault writes p as a SOAP Fault response. The status code is 500 as mandated
+// by SOAP 1.1, while SOAP 1.2 uses 400 for sender faults.
+func WriteSOAPFault(w http.ResponseWriter, p *problem.Problem, v SOAPVersion) error {
+	status := http.StatusInternalServerError
+	if s := problemStatus(p); v == SOAP12 && s >= 400 && s < 500 {
+		status = http.StatusBadRequest
+	}
+	w.Header().Set("Content-Type", v.ContentType())
+	w.WriteHeader(status)
+	return EncodeSOAPFault(w, p, v)
+}
+
+func soapDetail(data map[string]any) []soapDetailEntry {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		switch k {
+		case "type", "title", "status":
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	entries := make([]soapDetailEntry, 0, len(keys))
+	for _, k := range keys {
+		e := soapDetailEntry{XMLName: xml.Name{Local: k}, Value: soapValue(data[k])}
+		if !isXMLName(k) {
+			e.XMLName.Local, e.Name = "entry", k
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// isXMLName reports whether s can be written as the name of an element: an XML name
+// without namespace prefix, not starting with the reserved "xml".
+func isXMLName(s string) bool {
+	if s == "" || strings.HasPrefix(strings.ToLower(s), "xml") {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '_' || unicode.IsLetter(c):
+		case i > 0 && (c == '-' || c == '.' || unicode.IsDigit(c)):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func soapValue(v any) string {
+	switch v.(type) {
+	case map[string]any, []any:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
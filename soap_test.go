@@ -0,0 +1,48 @@
+package apierr_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/debyten/apierr"
+	"schneider.vip/problem"
+)
+
+func TestEncodeSOAPFaultExtensionNames(t *testing.T) {
+	p := apierr.BadRequest.Problem("invalid order").Append(
+		problem.Custom("order_id", 42),
+		problem.Custom("bad key", 1),
+		problem.Custom("1st", "a<b"),
+		problem.Custom("xmlns", "x"),
+	)
+	for _, v := range []apierr.SOAPVersion{apierr.SOAP11, apierr.SOAP12} {
+		var buf bytes.Buffer
+		if err := apierr.EncodeSOAPFault(&buf, p, v); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		dec := xml.NewDecoder(strings.NewReader(out))
+		for {
+			_, err := dec.Token()
+			if err != nil {
+				if err != io.EOF {
+					t.Fatalf("SOAP %d: invalid XML %v:\n%s", v, err, out)
+				}
+				break
+			}
+		}
+		for _, want := range []string{
+			"<order_id>42</order_id>",
+			`<entry name="bad key">1</entry>`,
+			`<entry name="1st">a&lt;b</entry>`,
+			`<entry name="xmlns">x</entry>`,
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("SOAP %d: missing %s in\n%s", v, want, out)
+			}
+		}
+	}
+}
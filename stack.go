@@ -0,0 +1,59 @@
+package apierr
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// StackOf returns the stack trace carried by the error chain of err, if any.
+//
+// Errors exposing a StackTrace() method are supported, whatever the returned type is
+// (e.g. github.com/pkg/errors StackTrace): the result is formatted with %+v, or
+// resolved to function and file:line when it is a []uintptr of program counters.
+// When several errors of the chain carry a stack trace, the deepest one wins since
+// it is the closest to the origin of the error.
+func StackOf(err error) (string, bool) {
+	return defaultRegistry.stackOf(err)
+}
+
+func (r *Registry) stackOf(err error) (string, bool) {
+	var stack string
+	found := false
+	for depth := 0; err != nil && depth < maxTreeDepth; depth++ {
+		if s, ok := stackTrace(err); ok {
+			stack, found = s, true
+		}
+		err = r.unwrap(err)
+	}
+	return stack, found
+}
+
+func stackTrace(err error) (string, bool) {
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return "", false
+	}
+	st := m.Call(nil)[0].Interface()
+	if pcs, ok := st.([]uintptr); ok {
+		if len(pcs) == 0 {
+			return "", false
+		}
+		return formatPCs(pcs), true
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%+v", st), "\n"), true
+}
+
+func formatPCs(pcs []uintptr) string {
+	var sb strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		fmt.Fprintf(&sb, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		if !more {
+			break
+		}
+	}
+	return sb.String()
+}
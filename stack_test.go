@@ -0,0 +1,35 @@
+package apierr_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/debyten/apierr"
+)
+
+// cyclicError is a hand-written wrapper whose chain loops back to itself.
+type cyclicError struct{ next error }
+
+func (e *cyclicError) Error() string { return "cyclic" }
+func (e *cyclicError) Unwrap() error { return e.next }
+
+func newCyclicError() error {
+	a, b := &cyclicError{}, &cyclicError{}
+	a.next, b.next = b, a
+	return a
+}
+
+func TestStackOfCyclicChain(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, ok := apierr.StackOf(newCyclicError()); ok {
+			t.Error("stack found in a chain without stack")
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("StackOf does not return on a cyclic chain")
+	}
+}
@@ -0,0 +1,63 @@
+package apierr
+
+import (
+	"runtime"
+
+	"schneider.vip/problem"
+)
+
+// StackTraceExtension is the problem extension carrying the stack trace of a server
+// error, see Registry.ExposeStackTraces.
+const StackTraceExtension = "stack"
+
+// StackTraceDepth is the maximum number of frames captured when stack traces are enabled.
+var StackTraceDepth = 32
+
+// StackTraceSkip is the number of frames skipped above the caller of the constructor,
+// e.g. 1 when APIErr are always created through a helper of the application.
+var StackTraceSkip = 0
+
+var stackTraces = false
+
+// EnableStackTraces enables the capture of the call stack by the APIErr constructors
+// (New, FromText, HttpStatus.Err...), see APIErr.StackTrace. It costs a runtime.Callers
+// per constructor call, hence it is disabled by default. Like StackTraceDepth and
+// StackTraceSkip, it applies to the whole process, since the errors are created before
+// any registry handles them.
+//
+// Example:
+//
+//	if debug {
+//		apierr.EnableStackTraces(true)
+//		apierr.DefaultRegistry().ExposeStackTraces = true
+//	}
+func EnableStackTraces(enabled bool) {
+	stackTraces = enabled
+}
+
+// StackTrace returns the program counters of the stack where the error was created,
+// nil unless stack traces are enabled (see EnableStackTraces). The stack is reported
+// by StackOf and sent to the notifiers.
+func (e *APIErr) StackTrace() []uintptr {
+	return e.stack
+}
+
+// callers returns the program counters of the stack, skip frames up.
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, StackTraceDepth)
+	// +1 skips callers itself
+	n := runtime.Callers(skip+StackTraceSkip+1, pcs)
+	return pcs[:n]
+}
+
+// exposeStack adds the StackTraceExtension to the server error problems, when enabled.
+func (r *Registry) exposeStack(err error, p *problem.Problem) *problem.Problem {
+	if !r.ExposeStackTraces || problemStatus(p) < 500 {
+		return p
+	}
+	stack, ok := r.stackOf(err)
+	if !ok {
+		return p
+	}
+	return cloneProblem(p).Append(problem.Custom(StackTraceExtension, stack))
+}
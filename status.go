@@ -28,6 +28,7 @@ const (
 	BadRequest                    HttpStatus = 400
 	InternalServerError           HttpStatus = 500
 	Unauthorized                  HttpStatus = 401
+	PaymentRequired               HttpStatus = 402
 	Forbidden                     HttpStatus = 403
 	MethodNotAllowed              HttpStatus = 405
 	NotAcceptable                 HttpStatus = 406
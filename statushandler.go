@@ -0,0 +1,28 @@
+package apierr
+
+import (
+	"net/http"
+)
+
+// StatusHandler returns an http.HandlerFunc replying with a problem of the given status,
+// using the default registry. See Registry.StatusHandler.
+func StatusHandler(status HttpStatus) http.HandlerFunc {
+	return defaultRegistry.StatusHandler(status)
+}
+
+// StatusHandler returns an http.HandlerFunc replying with a problem of the given status
+// through the registry (decorators, encoders, hooks). It plugs apierr in the error pages
+// hooks of the frameworks accepting standard handlers.
+//
+// Example with Beego:
+//
+//	web.ErrorHandler("404", apierr.StatusHandler(apierr.NotFound))
+//
+// Example with Iris:
+//
+//	app.OnErrorCode(iris.StatusNotFound, iris.FromStd(apierr.StatusHandler(apierr.NotFound)))
+func (r *Registry) StatusHandler(status HttpStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		r.HandleRequest(status.Problem(http.StatusText(int(status))), w, req)
+	}
+}
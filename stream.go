@@ -0,0 +1,141 @@
+package apierr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"schneider.vip/problem"
+)
+
+// NDJSONContentType is the media type of the JSON lines streams.
+const NDJSONContentType = "application/x-ndjson"
+
+// StreamOptions configures the error handling of a streaming endpoint, see Registry.StreamNDJSON.
+type StreamOptions struct {
+	// ErrorRecord returns the final record written when the stream fails after its
+	// first record; problem is the JSON problem of the error. When nil, the record
+	// is {"error": problem}.
+	ErrorRecord func(problem json.RawMessage) any
+}
+
+// NDJSONWriter writes the records of a JSON lines stream, flushing each one.
+type NDJSONWriter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	started bool
+}
+
+// Write writes record as a line of JSON and flushes it to the client.
+func (s *NDJSONWriter) Write(record any) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if !s.started {
+		s.w.Header().Set("Content-Type", NDJSONContentType)
+		s.started = true
+	}
+	if _, err = s.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err = s.rc.Flush(); errors.Is(err, http.ErrNotSupported) {
+		return nil
+	}
+	return err
+}
+
+// StreamFunc is a streaming endpoint writing its records to s.
+type StreamFunc func(s *NDJSONWriter, r *http.Request) error
+
+// StreamNDJSON adapts h to http.Handler using the default registry, see Registry.StreamNDJSON.
+func StreamNDJSON(h StreamFunc, opts StreamOptions) http.Handler {
+	return defaultRegistry.StreamNDJSON(h, opts)
+}
+
+// StreamNDJSON adapts the JSON lines streaming endpoint h to http.Handler. When h fails
+// before writing a record the error is replied as in Wrap. Once the stream has started
+// the status cannot change anymore: the problem of the error is written as a final
+// record (see StreamOptions.ErrorRecord), so that the clients can tell a failed stream
+// from a complete one. The hooks (logging, notifiers, observers...) run in both cases.
+//
+// Example:
+//
+//	mux.Handle("GET /exports", apierr.StreamNDJSON(func(s *apierr.NDJSONWriter, r *http.Request) error {
+//		rows, err := db.Export(r.Context())
+//		if err != nil {
+//			return err
+//		}
+//		for rows.Next() {
+//			if err := s.Write(rows.Record()); err != nil {
+//				return err
+//			}
+//		}
+//		return rows.Err()
+//	}), apierr.StreamOptions{})
+func (r *Registry) StreamNDJSON(h StreamFunc, opts StreamOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s := &NDJSONWriter{w: w, rc: http.NewResponseController(w)}
+		err := h(s, req)
+		switch {
+		case err == nil:
+		case !s.started:
+			r.HandleRequestISE(err, w, req)
+		default:
+			r.writeErrorRecord(s, req, err, opts)
+		}
+	})
+}
+
+// writeErrorRecord handles err as a problem+json response in a buffer, running the
+// hooks, then writes the problem as the final record of s.
+func (r *Registry) writeErrorRecord(s *NDJSONWriter, req *http.Request, err error, opts StreamOptions) {
+	bw := &bufferedWriter{ResponseWriter: s.w, header: http.Header{}}
+	preq := req.Clone(req.Context())
+	preq.Header.Set("Accept", problem.ContentTypeJSON)
+	r.HandleRequestISE(err, bw, preq)
+	if bw.body.Len() == 0 {
+		// e.g. the client went away
+		return
+	}
+	p := json.RawMessage(bytes.TrimSpace(bw.body.Bytes()))
+	if !json.Valid(p) {
+		// the fallback of the unknown errors is plain text
+		p = problem.Of(bw.status).JSON()
+	}
+	var record any = map[string]json.RawMessage{"error": p}
+	if opts.ErrorRecord != nil {
+		record = opts.ErrorRecord(p)
+	}
+	_ = s.Write(record)
+}
+
+// bufferedWriter captures a response, while the outcome and the write deadline
+// still reach the wrapped writer through Unwrap.
+type bufferedWriter struct {
+	http.ResponseWriter
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// Unwrap is used by http.ResponseController and by TrackOutcome.
+func (w *bufferedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,68 @@
+package apierr
+
+import (
+	"context"
+	"net/http"
+
+	"schneider.vip/problem"
+)
+
+// TraceIDExtension is the problem extension carrying the trace id, see Tracer.
+const TraceIDExtension = "trace_id"
+
+// TraceIDHeader is the response header carrying the trace id, see Tracer.
+const TraceIDHeader = "X-Trace-Id"
+
+// Tracer records the handled errors on the active span of the request context
+// (e.g. OpenTelemetry span.RecordError and SetStatus), including the unknown errors
+// replied with 500 by HandleISE.
+//
+// An OpenTelemetry Tracer is a few lines:
+//
+//	registry.Tracer = apierr.TracerFunc(func(ctx context.Context, err error, status int) string {
+//		span := trace.SpanFromContext(ctx)
+//		if !span.SpanContext().IsValid() {
+//			return ""
+//		}
+//		span.RecordError(err)
+//		if status >= 500 {
+//			span.SetStatus(codes.Error, err.Error())
+//		}
+//		return span.SpanContext().TraceID().String()
+//	})
+type Tracer interface {
+	// Record records err, replied with status, on the span of ctx and returns
+	// its trace id, empty when ctx has no span.
+	Record(ctx context.Context, err error, status int) string
+}
+
+// TracerFunc is an adapter to allow the use of ordinary functions as Tracer.
+type TracerFunc func(ctx context.Context, err error, status int) string
+
+// Record calls f(ctx, err, status).
+func (f TracerFunc) Record(ctx context.Context, err error, status int) string {
+	return f(ctx, err, status)
+}
+
+// trace records err with the Tracer of r, if any, and adds the trace id to the
+// problem (TraceIDExtension) and to the response (TraceIDHeader).
+func (r *Registry) trace(ctx context.Context, w http.ResponseWriter, err error, p *problem.Problem) *problem.Problem {
+	id := r.recordTrace(ctx, w, err, problemStatus(p))
+	if id == "" {
+		return p
+	}
+	return cloneProblem(p).Append(problem.Custom(TraceIDExtension, id))
+}
+
+// recordTrace records err, replied with status, with the Tracer of r, if any, and
+// sets the TraceIDHeader of the response. It returns the trace id, empty if none.
+func (r *Registry) recordTrace(ctx context.Context, w http.ResponseWriter, err error, status int) string {
+	if r.Tracer == nil {
+		return ""
+	}
+	id := r.Tracer.Record(ctx, err, status)
+	if id != "" {
+		w.Header().Set(TraceIDHeader, id)
+	}
+	return id
+}
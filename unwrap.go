@@ -0,0 +1,44 @@
+package apierr
+
+import "slices"
+
+// Unwrapper returns the error wrapped by err, or nil if there is none.
+// It is used by Handle to walk error chains built by libraries
+// that do not implement Unwrap.
+type Unwrapper func(err error) error
+
+// AddUnwrapper appends u to the unwrappers chain of the default registry, see Registry.AddUnwrapper.
+func AddUnwrapper(u Unwrapper) {
+	defaultRegistry.AddUnwrapper(u)
+}
+
+// AddUnwrapper appends u to the unwrappers chain. For every error the unwrappers
+// are tried in order and the first non nil result is used.
+// The chain starts with errors.Unwrap and CauseUnwrapper.
+func (r *Registry) AddUnwrapper(u Unwrapper) {
+	if r.unwrappers == nil {
+		r.unwrappers = slices.Clone(defaultUnwrappers)
+	}
+	r.unwrappers = append(r.unwrappers, u)
+}
+
+// CauseUnwrapper unwraps errors exposing a Cause() error method (github.com/pkg/errors style).
+func CauseUnwrapper(err error) error {
+	if c, ok := err.(interface{ Cause() error }); ok {
+		return c.Cause()
+	}
+	return nil
+}
+
+func (r *Registry) unwrap(err error) error {
+	unwrappers := r.unwrappers
+	if unwrappers == nil {
+		unwrappers = defaultUnwrappers
+	}
+	for _, u := range unwrappers {
+		if next := u(err); next != nil {
+			return next
+		}
+	}
+	return nil
+}
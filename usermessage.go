@@ -0,0 +1,56 @@
+package apierr
+
+import (
+	"strings"
+
+	"schneider.vip/problem"
+)
+
+// UserMessageExtension is the problem extension carrying a human message safe
+// to be shown by user interfaces, distinct from the technical title.
+const UserMessageExtension = "user_message"
+
+// DefaultServerUserMessage is written by Handle in place of the user message
+// of a server error when the message exposes internal error text.
+var DefaultServerUserMessage = "Something went wrong on our side. Please try again later."
+
+// WithUserMessage returns the problem.Option setting the UserMessageExtension.
+//
+// Example:
+//
+//	apierr.Conflict.Problem("version mismatch on order 42").
+//		Append(apierr.WithUserMessage("The order was modified by someone else, please reload it."))
+func WithUserMessage(msg string) problem.Option {
+	return problem.Custom(UserMessageExtension, msg)
+}
+
+// sanitizeUserMessage enforces that server errors never leak internals through the
+// user message: when the message contains the text of an error of the chain
+// it is replaced with DefaultServerUserMessage.
+// p is not modified, a copy is returned instead.
+func (r *Registry) sanitizeUserMessage(err error, p *problem.Problem) *problem.Problem {
+	data := problemData(p)
+	msg, ok := data[UserMessageExtension].(string)
+	if status, _ := data["status"].(float64); !ok || status < 500 {
+		return p
+	}
+	if !r.leaksInternals(msg, err, data) {
+		return p
+	}
+	return cloneProblem(p).Append(problem.Custom(UserMessageExtension, DefaultServerUserMessage))
+}
+
+func (r *Registry) leaksInternals(msg string, err error, data map[string]any) bool {
+	if reason, ok := data["reason"].(string); ok && reason != "" && strings.Contains(msg, reason) {
+		return true
+	}
+	for depth := 0; err != nil && depth < maxTreeDepth; depth, err = depth+1, r.unwrap(err) {
+		if _, ok := err.(*problem.Problem); ok {
+			continue
+		}
+		if text := err.Error(); text != "" && strings.Contains(msg, text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package apierr
+
+import (
+	"testing"
+	"time"
+)
+
+// loopError is a hand-written wrapper whose chain loops back to itself.
+type loopError struct{ next error }
+
+func (e *loopError) Error() string { return "loop failure" }
+func (e *loopError) Unwrap() error { return e.next }
+
+func TestSanitizeUserMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		status HttpStatus
+		msg    string
+		want   string
+	}{
+		{"client error", BadRequest, "loop failure", "loop failure"},
+		{"safe message", ServiceUnavailable, "Please retry in a minute.", "Please retry in a minute."},
+		{"leaking message", ServiceUnavailable, "Failed: loop failure", DefaultServerUserMessage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := &loopError{}, &loopError{}
+			a.next, b.next = b, a
+			p := tt.status.Problem("backend down").Append(WithUserMessage(tt.msg))
+			got := make(chan any, 1)
+			go func() {
+				got <- problemData(NewRegistry().sanitizeUserMessage(a, p))[UserMessageExtension]
+			}()
+			select {
+			case msg := <-got:
+				if msg != tt.want {
+					t.Errorf("user message = %q, want %q", msg, tt.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("sanitizeUserMessage does not return on a cyclic chain")
+			}
+		})
+	}
+}
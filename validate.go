@@ -0,0 +1,128 @@
+package apierr
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// Validate cross-checks the configuration of the default registry, see Registry.Validate.
+//
+// Run it in CI, e.g. from a test of the package that configures apierr:
+//
+//	func TestErrorConfiguration(t *testing.T) {
+//		if err := apierr.Validate(store.ErrConflict, store.ErrNotFound); err != nil {
+//			t.Fatal(err)
+//		}
+//	}
+func Validate(samples ...error) error {
+	return defaultRegistry.Validate(samples...)
+}
+
+// Validate cross-checks the configuration of r and returns the inconsistencies
+// found, joined with errors.Join:
+//
+//   - problem types registered more than once with RegisterType;
+//   - problem types with an empty or invalid type URI;
+//   - extensions declared with different types by the problem types;
+//   - codes registered more than once with Register, or an invalid SetTypeBaseURL;
+//   - codes translated in some language but not in the others (see RegisterTranslations);
+//   - sentinels of Map that cannot be reached, because an earlier sentinel or a
+//     handler matches them first;
+//   - handlers that cannot be reached: they match some of the samples (or of the
+//     sentinels of Map) but always after a higher-priority handler.
+//
+// The handlers are functions, hence their reachability is only checked against the
+// samples, typically the sentinel and typed errors of the service.
+func (r *Registry) Validate(samples ...error) error {
+	errs := r.validateTypes()
+	errs = append(errs, r.validateExtensions()...)
+	errs = append(errs, r.validateCodes()...)
+	errs = append(errs, r.validateTranslations()...)
+	errs = append(errs, r.validateRules(samples)...)
+	return errors.Join(errs...)
+}
+
+// validateTypes reports the problem types registered more than once or with an invalid type URI.
+func (r *Registry) validateTypes() []error {
+	var errs []error
+	seen := map[string]bool{}
+	for _, pt := range r.problemTypes {
+		if seen[pt.uri] {
+			errs = append(errs, fmt.Errorf("problem type %q registered more than once", pt.uri))
+		}
+		seen[pt.uri] = true
+		if pt.uri == "" {
+			errs = append(errs, errors.New("problem type with empty uri"))
+		} else if _, err := url.Parse(pt.uri); err != nil {
+			errs = append(errs, fmt.Errorf("problem type %q: %w", pt.uri, err))
+		}
+	}
+	return errs
+}
+
+// validateRules returns the unreachable sentinels and handlers of r, see Validate.
+func (r *Registry) validateRules(samples []error) []error {
+	var errs []error
+	for i, s := range r.sentinels {
+		if j := r.firstHandler(s.sentinel); j >= 0 {
+			errs = append(errs, fmt.Errorf("sentinel %q is shadowed by %s", s.sentinel, r.handlerName(j)))
+			continue
+		}
+		for _, prev := range r.sentinels[:i] {
+			if errors.Is(s.sentinel, prev.sentinel) {
+				errs = append(errs, fmt.Errorf("sentinel %q is shadowed by sentinel %q", s.sentinel, prev.sentinel))
+				break
+			}
+		}
+	}
+	first := map[int]bool{}
+	shadowed := map[int]int{}
+	for _, err := range append(r.sentinelErrors(), samples...) {
+		if p, _ := r.findProblem(err); p != nil {
+			continue
+		}
+		winner := -1
+		for i, h := range r.handlers {
+			if h.Handler(err) == nil {
+				continue
+			}
+			if winner < 0 {
+				// a quarantined handler is discarded outside of its rollout, hence
+				// it does not shadow the next ones
+				first[i] = true
+				if !h.Quarantined {
+					winner = i
+				}
+				continue
+			}
+			if _, ok := shadowed[i]; !ok {
+				shadowed[i] = winner
+			}
+		}
+	}
+	for i := range r.handlers {
+		if j, ok := shadowed[i]; ok && !first[i] {
+			errs = append(errs, fmt.Errorf("%s is shadowed by %s", r.handlerName(i), r.handlerName(j)))
+		}
+	}
+	return errs
+}
+
+// firstHandler returns the index of the first handler, not quarantined, converting err; -1 if none.
+func (r *Registry) firstHandler(err error) int {
+	for i, h := range r.handlers {
+		if !h.Quarantined && h.Handler(err) != nil {
+			return i
+		}
+	}
+	return -1
+}
+
+// handlerName describes the handler i of r, for the unnamed ones by position.
+func (r *Registry) handlerName(i int) string {
+	if name := r.handlers[i].Name; name != "" {
+		return fmt.Sprintf("handler %q", name)
+	}
+	return fmt.Sprintf("handler #%d", i)
+}
@@ -0,0 +1,77 @@
+package apierr
+
+import (
+	"strings"
+
+	"schneider.vip/problem"
+)
+
+// ValidationExtension is the problem extension listing the violations of a ValidationError.
+const ValidationExtension = "errors"
+
+// Violation is a field-level validation failure.
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+}
+
+// ValidationError is a builder of 422 problems listing field-level violations.
+//
+// Example:
+//
+//	v := apierr.Validation()
+//	if !isEmail(req.Email) {
+//		v.Field("email", "must be valid")
+//	}
+//	if req.Age < 18 {
+//		v.FieldCode("age", "too_young", "must be >= 18")
+//	}
+//	if err := v.Err(); err != nil {
+//		return err
+//	}
+type ValidationError struct {
+	violations []Violation
+}
+
+// Validation returns an empty ValidationError.
+func Validation() *ValidationError {
+	return &ValidationError{}
+}
+
+// Field adds a violation of field.
+func (v *ValidationError) Field(field, message string) *ValidationError {
+	return v.FieldCode(field, "", message)
+}
+
+// FieldCode adds a violation of field with a machine-readable code.
+func (v *ValidationError) FieldCode(field, code, message string) *ValidationError {
+	v.violations = append(v.violations, Violation{Field: field, Message: message, Code: code})
+	return v
+}
+
+// Violations returns the violations added so far.
+func (v *ValidationError) Violations() []Violation {
+	return v.violations
+}
+
+// Err returns v if it has violations, nil otherwise.
+func (v *ValidationError) Err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return v
+}
+
+func (v *ValidationError) Error() string {
+	parts := make([]string, len(v.violations))
+	for i, vi := range v.violations {
+		parts[i] = vi.Field + ": " + vi.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Problem converts v to a 422 problem with the ValidationExtension.
+func (v *ValidationError) Problem() *problem.Problem {
+	return UnprocessableEntity.Problem("validation failed").Append(problem.Custom(ValidationExtension, v.violations))
+}
@@ -0,0 +1,105 @@
+package apierr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// WarningsExtension is the member of the success responses listing the warnings of the request.
+const WarningsExtension = "warnings"
+
+// warningCode is the Warning header code of the notices: 199 Miscellaneous Warning.
+const warningCode = "199"
+
+type warningsKey struct{}
+
+// warnings accumulates the notices of a request, possibly from several goroutines.
+type warnings struct {
+	mu    sync.Mutex
+	texts []string
+}
+
+// CollectWarnings is a middleware making Warn available to the next handlers: the
+// notices are emitted by WriteJSON.
+func CollectWarnings(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), warningsKey{}, &warnings{})))
+	})
+}
+
+// Warn records a non-fatal notice for the request of ctx, e.g. a degraded result
+// served from a stale cache. It is a no-op when ctx does not come from CollectWarnings.
+//
+// Example:
+//
+//	if err != nil {
+//		apierr.Warn(r.Context(), "recommendations unavailable")
+//	}
+func Warn(ctx context.Context, text string) {
+	if ws, ok := ctx.Value(warningsKey{}).(*warnings); ok {
+		ws.mu.Lock()
+		ws.texts = append(ws.texts, text)
+		ws.mu.Unlock()
+	}
+}
+
+// WarningsOf returns the notices recorded with Warn for the request of ctx.
+func WarningsOf(ctx context.Context) []string {
+	ws, ok := ctx.Value(warningsKey{}).(*warnings)
+	if !ok {
+		return nil
+	}
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return append([]string(nil), ws.texts...)
+}
+
+// WriteJSON writes v as the JSON success response of r with status. The warnings of
+// the request (see Warn) are sent in Warning headers and, when v is a JSON object,
+// in its WarningsExtension member.
+func WriteJSON(w http.ResponseWriter, r *http.Request, status int, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if ws := WarningsOf(r.Context()); len(ws) > 0 {
+		for _, text := range ws {
+			w.Header().Add("Warning", warningValue(text))
+		}
+		if body, err = withWarnings(body, ws); err != nil {
+			return err
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	return err
+}
+
+var quotedStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// warningValue returns the Warning header value of text, without agent nor date.
+func warningValue(text string) string {
+	return warningCode + ` - "` + quotedStringEscaper.Replace(text) + `"`
+}
+
+// withWarnings adds the WarningsExtension member to body, when it is a JSON object.
+func withWarnings(body []byte, ws []string) ([]byte, error) {
+	if !bytes.HasPrefix(body, []byte("{")) {
+		return body, nil
+	}
+	members := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &members); err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(ws)
+	if err != nil {
+		return nil, err
+	}
+	members[WarningsExtension] = raw
+	return json.Marshal(members)
+}
@@ -0,0 +1,75 @@
+package apierr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type webhookPayload struct {
+	ID          string            `json:"id,omitempty"`
+	Status      int               `json:"status"`
+	Title       string            `json:"title,omitempty"`
+	Type        string            `json:"type,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	Time        time.Time         `json:"time"`
+	Fingerprint string            `json:"fingerprint"`
+	Suppressed  int               `json:"suppressed,omitempty"`
+	Origin      string            `json:"origin,omitempty"`
+	Values      map[string]string `json:"values,omitempty"`
+}
+
+// Webhook returns a Notifier posting the notifications as JSON to url with client
+// (http.DefaultClient when nil). It honors the registry HookTimeout.
+//
+// Example:
+//
+//	apierr.AddNotifier(apierr.Dedup(apierr.Webhook(alertsURL, nil), 10*time.Minute))
+func Webhook(url string, client *http.Client) ContextNotifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &webhookNotifier{url: url, client: client}
+}
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (wh *webhookNotifier) Notify(n Notification) {
+	wh.NotifyContext(context.Background(), n)
+}
+
+func (wh *webhookNotifier) NotifyContext(ctx context.Context, n Notification) {
+	payload := webhookPayload{
+		ID:          n.ID,
+		Status:      n.Status,
+		Title:       n.Title,
+		Type:        n.Type,
+		Time:        n.Time,
+		Fingerprint: n.Fingerprint(),
+		Suppressed:  n.Suppressed,
+		Origin:      n.Origin,
+		Values:      n.Values,
+	}
+	if n.Err != nil {
+		payload.Error = n.Err.Error()
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.url, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return
+	}
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,32 @@
+package apierr
+
+import (
+	"net/http"
+)
+
+// HandlerFunc is an http handler returning an error.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Wrap adapts h to http.Handler using the default registry, see Registry.Wrap.
+//
+// Example:
+//
+//	mux.Handle("GET /users/{id}", apierr.Wrap(func(w http.ResponseWriter, r *http.Request) error {
+//		user, err := svc.FindByID(r.Context(), r.PathValue("id"))
+//		if err != nil {
+//			return err
+//		}
+//		return json.NewEncoder(w).Encode(user)
+//	}))
+func Wrap(h HandlerFunc) http.Handler {
+	return defaultRegistry.Wrap(h)
+}
+
+// Wrap adapts h to http.Handler: the error returned by h, if any, is handled with HandleRequestISE.
+func (r *Registry) Wrap(h HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := h(w, req); err != nil {
+			r.HandleRequestISE(err, w, req)
+		}
+	})
+}
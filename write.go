@@ -0,0 +1,72 @@
+package apierr
+
+import (
+	"net/http"
+	"time"
+
+	"schneider.vip/problem"
+)
+
+// DefaultErrorWriteTimeout is the write deadline of the error responses of the
+// registries not setting Registry.ErrorWriteTimeout.
+const DefaultErrorWriteTimeout = 5 * time.Second
+
+// StatusOverrider returns the status to write for a problem of the given status.
+type StatusOverrider func(status int) int
+
+// DefaultStatusOverrider is used by Handle to force the outgoing status regardless of the
+// problem status (e.g. a WAF policy replying 404 to every 403). The default keeps the status unchanged.
+var DefaultStatusOverrider StatusOverrider = func(status int) int {
+	return status
+}
+
+// WriteProblemWithStatus writes p with the given status regardless of the status of p.
+// The "status" member of the body is set accordingly; p is not modified.
+func WriteProblemWithStatus(w http.ResponseWriter, p *problem.Problem, status int) (int, error) {
+	return withStatus(p, status).WriteTo(w)
+}
+
+// withStatus returns p if its status is already status, a copy with the status replaced otherwise.
+func withStatus(p *problem.Problem, status int) *problem.Problem {
+	data := problemData(p)
+	if current, _ := data["status"].(float64); int(current) == status {
+		return p
+	}
+	return cloneProblem(p).Append(problem.Status(status))
+}
+
+// writeProblem writes p to w with enc, honoring Registry.ErrorWriteTimeout. Writers not
+// supporting deadlines or flushing are written to as usual.
+func (r *Registry) writeProblem(w http.ResponseWriter, p *problem.Problem, enc Encoder) {
+	rc := http.NewResponseController(w)
+	timeout := r.ErrorWriteTimeout
+	if timeout == 0 {
+		timeout = DefaultErrorWriteTimeout
+	}
+	if timeout > 0 {
+		_ = rc.SetWriteDeadline(time.Now().Add(timeout))
+	}
+	status := problemStatus(p)
+	if !bodyAllowed(status) {
+		// e.g. 304 Not Modified: the problem is not written
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", enc.ContentType())
+	if status != 0 {
+		w.WriteHeader(status)
+	}
+	_ = enc.Encode(w, p)
+	_ = rc.Flush()
+}
+
+// bodyAllowed reports whether a response with status can have a body.
+func bodyAllowed(status int) bool {
+	switch {
+	case status == 0:
+		return true
+	case status < 200, status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}